@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+// ConvertedSpecAnnotation holds the v1beta1 spec fields that have no v1alpha1 equivalent,
+// so they are not lost when an IngressConfig is read and written back through v1alpha1.
+const ConvertedSpecAnnotation = "kube-botblocker.github.io/v1beta1-spec"
+
+// convertedSpec is the content of ConvertedSpecAnnotation.
+type convertedSpec struct {
+	// BlockedUserAgents holds only the entries with a comment.
+	BlockedUserAgents      []v1beta1.UserAgentRule    `json:"blockedUserAgents,omitempty"`
+	Response               *v1beta1.BlockResponse     `json:"response,omitempty"`
+	NamespaceSelector      *metav1.LabelSelector      `json:"namespaceSelector,omitempty"`
+	RolloutDeadlineSeconds *int32                     `json:"rolloutDeadlineSeconds,omitempty"`
+	MarkerRepairPolicy     v1beta1.MarkerRepairPolicy `json:"markerRepairPolicy,omitempty"`
+	DeletionPolicy         v1beta1.DeletionPolicy     `json:"deletionPolicy,omitempty"`
+}
+
+// ConvertTo converts this IngressConfig to the Hub version (v1beta1).
+func (src *IngressConfig) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.IngressConfig)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	var saved convertedSpec
+	if raw, ok := dst.Annotations[ConvertedSpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+			return err
+		}
+		delete(dst.Annotations, ConvertedSpecAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	comments := make(map[string]string, len(saved.BlockedUserAgents))
+	for _, rule := range saved.BlockedUserAgents {
+		comments[rule.Pattern] = rule.Comment
+	}
+
+	dst.Spec = v1beta1.IngressConfigSpec{
+		Suspend: src.Spec.Suspend,
+	}
+	if saved.Response != nil {
+		dst.Spec.Response = *saved.Response
+	}
+	dst.Spec.NamespaceSelector = saved.NamespaceSelector
+	dst.Spec.RolloutDeadlineSeconds = saved.RolloutDeadlineSeconds
+	dst.Spec.MarkerRepairPolicy = saved.MarkerRepairPolicy
+	dst.Spec.DeletionPolicy = saved.DeletionPolicy
+	if src.Spec.BlockedUserAgents != nil {
+		dst.Spec.BlockedUserAgents = make([]v1beta1.UserAgentRule, 0, len(src.Spec.BlockedUserAgents))
+		for _, pattern := range src.Spec.BlockedUserAgents {
+			dst.Spec.BlockedUserAgents = append(dst.Spec.BlockedUserAgents, v1beta1.UserAgentRule{
+				Pattern: pattern,
+				Comment: comments[pattern],
+			})
+		}
+	}
+
+	dst.Status = v1beta1.IngressConfigStatus{
+		LastUpdated:        src.Status.LastUpdated.DeepCopy(),
+		Conditions:         copyConditions(src.Status.Conditions),
+		SpecHash:           src.Status.SpecHash,
+		ObservedGeneration: src.Status.ObservedGeneration,
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *IngressConfig) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.IngressConfig)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = IngressConfigSpec{
+		Suspend: src.Spec.Suspend,
+	}
+	if src.Spec.BlockedUserAgents != nil {
+		dst.Spec.BlockedUserAgents = src.Spec.Patterns()
+	}
+
+	var saved convertedSpec
+	for _, rule := range src.Spec.BlockedUserAgents {
+		if rule.Comment != "" {
+			saved.BlockedUserAgents = append(saved.BlockedUserAgents, rule)
+		}
+	}
+	if src.Spec.Response != (v1beta1.BlockResponse{}) {
+		saved.Response = src.Spec.Response.DeepCopy()
+	}
+	saved.NamespaceSelector = src.Spec.NamespaceSelector.DeepCopy()
+	if src.Spec.RolloutDeadlineSeconds != nil {
+		deadline := *src.Spec.RolloutDeadlineSeconds
+		saved.RolloutDeadlineSeconds = &deadline
+	}
+	saved.MarkerRepairPolicy = src.Spec.MarkerRepairPolicy
+	saved.DeletionPolicy = src.Spec.DeletionPolicy
+	if saved.BlockedUserAgents != nil || saved.Response != nil || saved.NamespaceSelector != nil ||
+		saved.RolloutDeadlineSeconds != nil || saved.MarkerRepairPolicy != "" || saved.DeletionPolicy != "" {
+		raw, err := json.Marshal(saved)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = make(map[string]string)
+		}
+		dst.Annotations[ConvertedSpecAnnotation] = string(raw)
+	}
+
+	dst.Status = IngressConfigStatus{
+		LastUpdated:        src.Status.LastUpdated.DeepCopy(),
+		Conditions:         copyConditions(src.Status.Conditions),
+		SpecHash:           src.Status.SpecHash,
+		ObservedGeneration: src.Status.ObservedGeneration,
+	}
+	if condition := lastCondition(src.Status.Conditions); condition != nil {
+		dst.Status.LastConditionStatus = condition.Status
+		dst.Status.LastConditionMessage = condition.Message
+	}
+
+	return nil
+}
+
+// lastCondition returns the condition that was mirrored into LastConditionStatus and LastConditionMessage
+// before these fields were dropped in v1beta1. Cleanup only happens once an IngressConfig is being deleted,
+// so its condition always takes precedence over the update one.
+func lastCondition(conditions []metav1.Condition) *metav1.Condition {
+	if condition := meta.FindStatusCondition(conditions, v1beta1.ConditionTypeCleanupSucceeded); condition != nil {
+		return condition
+	}
+	return meta.FindStatusCondition(conditions, v1beta1.ConditionTypeUpdateSucceeded)
+}
+
+func copyConditions(conditions []metav1.Condition) []metav1.Condition {
+	if conditions == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(conditions))
+	for i := range conditions {
+		conditions[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
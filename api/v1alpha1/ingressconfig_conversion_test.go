@@ -0,0 +1,208 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+func TestIngressConfigRoundTripFromV1alpha1(t *testing.T) {
+	lastUpdated := metav1.NewTime(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name string
+		obj  *IngressConfig
+	}{
+		{
+			name: "Empty object",
+			obj:  &IngressConfig{},
+		},
+		{
+			name: "Spec only",
+			obj: &IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "blocklist", Namespace: "kube-botblocker"},
+				Spec: IngressConfigSpec{
+					BlockedUserAgents: []string{"GPTBot", "ClaudeBot"},
+					Suspend:           true,
+				},
+			},
+		},
+		{
+			name: "Spec and status",
+			obj: &IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "blocklist",
+					Namespace:   "kube-botblocker",
+					Annotations: map[string]string{"example.com/owner": "team-a"},
+				},
+				Spec: IngressConfigSpec{
+					BlockedUserAgents: []string{"GPTBot"},
+				},
+				Status: IngressConfigStatus{
+					LastUpdated:          &lastUpdated,
+					LastConditionStatus:  metav1.ConditionTrue,
+					LastConditionMessage: "Ready for usage",
+					Conditions: []metav1.Condition{{
+						Type:               v1beta1.ConditionTypeUpdateSucceeded,
+						Status:             metav1.ConditionTrue,
+						Reason:             v1beta1.ConditionReasonReconciliationSuccessful,
+						Message:            "Ready for usage",
+						LastTransitionTime: lastUpdated,
+					}},
+					SpecHash:           "6ba9a2e583e163a90764393df1bcd8695fca8558c0dbcbe8d4524eeeb24346fe",
+					ObservedGeneration: 3,
+				},
+			},
+		},
+		{
+			name: "Saved v1beta1 fields",
+			obj: &IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "blocklist",
+					Annotations: map[string]string{
+						ConvertedSpecAnnotation: `{"blockedUserAgents":[{"pattern":"GPTBot","comment":"OpenAI crawler"}],` +
+							`"response":{"statusCode":444}}`,
+					},
+				},
+				Spec: IngressConfigSpec{
+					BlockedUserAgents: []string{"GPTBot", "ClaudeBot"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := &v1beta1.IngressConfig{}
+			if err := tt.obj.ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo() error: %v", err)
+			}
+			got := &IngressConfig{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.obj) {
+				t.Errorf("Round trip value - got: %+v, expected: %+v", got, tt.obj)
+			}
+		})
+	}
+}
+
+func TestIngressConfigRoundTripFromV1beta1(t *testing.T) {
+	lastUpdated := metav1.NewTime(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	rolloutDeadline := int32(600)
+
+	tests := []struct {
+		name string
+		obj  *v1beta1.IngressConfig
+	}{
+		{
+			name: "Empty object",
+			obj:  &v1beta1.IngressConfig{},
+		},
+		{
+			name: "Fields without v1alpha1 equivalent",
+			obj: &v1beta1.IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "blocklist", Namespace: "kube-botblocker"},
+				Spec: v1beta1.IngressConfigSpec{
+					BlockedUserAgents: []v1beta1.UserAgentRule{
+						{Pattern: "GPTBot", Comment: "OpenAI crawler"},
+						{Pattern: "ClaudeBot"},
+					},
+					Response: v1beta1.BlockResponse{StatusCode: 444},
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"kube-botblocker.github.io/protected": "true"},
+					},
+					DeletionPolicy:         v1beta1.DeletionPolicyBlock,
+					MarkerRepairPolicy:     v1beta1.MarkerRepairRebuildManagedBlock,
+					RolloutDeadlineSeconds: &rolloutDeadline,
+					Suspend:                true,
+				},
+			},
+		},
+		{
+			name: "Status with cleanup in progress",
+			obj: &v1beta1.IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "blocklist"},
+				Spec: v1beta1.IngressConfigSpec{
+					BlockedUserAgents: []v1beta1.UserAgentRule{{Pattern: "GPTBot"}},
+				},
+				Status: v1beta1.IngressConfigStatus{
+					LastUpdated: &lastUpdated,
+					Conditions: []metav1.Condition{
+						{
+							Type:               v1beta1.ConditionTypeUpdateSucceeded,
+							Status:             metav1.ConditionTrue,
+							Reason:             v1beta1.ConditionReasonReconciliationSuccessful,
+							Message:            "Ready for usage",
+							LastTransitionTime: lastUpdated,
+						},
+						{
+							Type:               v1beta1.ConditionTypeCleanupSucceeded,
+							Status:             metav1.ConditionFalse,
+							Reason:             v1beta1.ConditionReasonCleanupInProgress,
+							Message:            "Cleaning configuration before removal",
+							LastTransitionTime: lastUpdated,
+						},
+					},
+					SpecHash:           "6ba9a2e583e163a90764393df1bcd8695fca8558c0dbcbe8d4524eeeb24346fe",
+					ObservedGeneration: 2,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spoke := &IngressConfig{}
+			if err := spoke.ConvertFrom(tt.obj); err != nil {
+				t.Fatalf("ConvertFrom() error: %v", err)
+			}
+			got := &v1beta1.IngressConfig{}
+			if err := spoke.ConvertTo(got); err != nil {
+				t.Fatalf("ConvertTo() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.obj) {
+				t.Errorf("Round trip value - got: %+v, expected: %+v", got, tt.obj)
+			}
+		})
+	}
+}
+
+func TestIngressConfigConvertFromLastCondition(t *testing.T) {
+	hub := &v1beta1.IngressConfig{
+		Status: v1beta1.IngressConfigStatus{
+			Conditions: []metav1.Condition{
+				{Type: v1beta1.ConditionTypeUpdateSucceeded, Status: metav1.ConditionTrue, Message: "Ready for usage"},
+				{Type: v1beta1.ConditionTypeCleanupSucceeded, Status: metav1.ConditionFalse, Message: "Cleaning"},
+			},
+		},
+	}
+
+	got := &IngressConfig{}
+	if err := got.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error: %v", err)
+	}
+	if got.Status.LastConditionStatus != metav1.ConditionFalse || got.Status.LastConditionMessage != "Cleaning" {
+		t.Errorf("ConvertFrom() last condition - got: %s %q, expected: %s %q",
+			got.Status.LastConditionStatus, got.Status.LastConditionMessage, metav1.ConditionFalse, "Cleaning")
+	}
+}
@@ -27,6 +27,11 @@ type IngressConfigSpec struct {
 	// +kubebuilder:validation:Required
 	// +listType=set
 	BlockedUserAgents []string `json:"blockedUserAgents"`
+
+	// Suspend stops the rollout of this IngressConfig to the Ingresses referencing it.
+	// Configuration already present on those Ingresses is kept as is until Suspend is set back to false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // IngressConfigStatus defines the observed state of IngressConfig.
@@ -89,4 +94,8 @@ const (
 
 	ConditionTypeCleanupSucceeded    string = "CleanupSucceeded"
 	ConditionReasonCleanupInProgress string = "CleanupInProgress"
+
+	ConditionTypeSuspended   string = "Suspended"
+	ConditionReasonSuspended string = "SuspendedBySpec"
+	ConditionReasonResumed   string = "Resumed"
 )
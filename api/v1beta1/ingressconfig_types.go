@@ -0,0 +1,304 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultBlockStatusCode is the status code returned to blocked requests when none is specified.
+const DefaultBlockStatusCode int32 = 403
+
+// IngressConfigSpec defines the desired state of IngressConfig.
+type IngressConfigSpec struct {
+	// List of User-Agents to be added to the blocklist in each protected Ingress
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=map
+	// +listMapKey=pattern
+	BlockedUserAgents []UserAgentRule `json:"blockedUserAgents"`
+
+	// Response configures how blocked requests are answered.
+	// +optional
+	Response BlockResponse `json:"response,omitempty"`
+
+	// NamespaceSelector restricts the namespaces whose Ingresses may reference this IngressConfig.
+	// If not set, Ingresses from every namespace watched by the operator may reference it.
+	// Ignored when the operator only watches its own namespace.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// DeletionPolicy defines what happens to the Ingresses referencing this IngressConfig when it is deleted.
+	// Defaults to Cascade.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// MarkerRepairPolicy defines what happens when the markers around the configuration generated in an Ingress
+	// server-snippet were edited or removed. Defaults to Fail.
+	// +optional
+	MarkerRepairPolicy MarkerRepairPolicy `json:"markerRepairPolicy,omitempty"`
+
+	// RolloutDeadlineSeconds is the maximum time in seconds for a rollout to reach all Ingresses referencing
+	// this IngressConfig. Once exceeded, the IngressConfig is reported as Degraded. No deadline if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RolloutDeadlineSeconds *int32 `json:"rolloutDeadlineSeconds,omitempty"`
+
+	// Suspend stops the rollout of this IngressConfig to the Ingresses referencing it.
+	// Configuration already present on those Ingresses is kept as is until Suspend is set back to false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// DeletionPolicy defines how the Ingresses referencing a deleted IngressConfig are handled.
+// +kubebuilder:validation:Enum=Cascade;Block;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyCascade removes the reference and the generated configuration from every Ingress.
+	DeletionPolicyCascade DeletionPolicy = "Cascade"
+	// DeletionPolicyBlock refuses the deletion while Ingresses still reference the IngressConfig.
+	DeletionPolicyBlock DeletionPolicy = "Block"
+	// DeletionPolicyOrphan deletes the IngressConfig leaving the Ingresses and their configuration untouched.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// MarkerRepairPolicy defines how a server-snippet with corrupted markers is handled.
+// +kubebuilder:validation:Enum=Fail;RemoveOrphanMarkers;RebuildManagedBlock
+type MarkerRepairPolicy string
+
+const (
+	// MarkerRepairFail leaves the Ingress untouched until the server-snippet is fixed manually.
+	MarkerRepairFail MarkerRepairPolicy = "Fail"
+	// MarkerRepairRemoveOrphanMarkers removes the markers that are not part of a complete configuration block,
+	// then updates that block or appends a new one.
+	MarkerRepairRemoveOrphanMarkers MarkerRepairPolicy = "RemoveOrphanMarkers"
+	// MarkerRepairRebuildManagedBlock removes every marker and generated line and writes a new configuration
+	// block where the corrupted one was.
+	MarkerRepairRebuildManagedBlock MarkerRepairPolicy = "RebuildManagedBlock"
+)
+
+// UserAgentRule is a single entry of the User-Agent blocklist.
+type UserAgentRule struct {
+	// Pattern is matched against the User-Agent header of each request using a
+	// case insensitive regular expression (NGINX ~* operator).
+	// +kubebuilder:validation:MinLength=1
+	Pattern string `json:"pattern"`
+
+	// Comment is a free-form note about the entry, such as why it is blocked. It is not used by the operator.
+	// +optional
+	Comment string `json:"comment,omitempty"`
+}
+
+// BlockResponse configures the response sent to blocked requests.
+type BlockResponse struct {
+	// StatusCode is the HTTP status code returned to blocked requests. 444 is a NGINX specific
+	// code that closes the connection without sending a response. Defaults to 403.
+	// +kubebuilder:validation:Enum=403;404;410;429;444
+	// +optional
+	StatusCode int32 `json:"statusCode,omitempty"`
+}
+
+// Patterns returns the patterns of all BlockedUserAgents entries, in order.
+func (s *IngressConfigSpec) Patterns() []string {
+	patterns := make([]string, 0, len(s.BlockedUserAgents))
+	for _, rule := range s.BlockedUserAgents {
+		patterns = append(patterns, rule.Pattern)
+	}
+	return patterns
+}
+
+// GetStatusCode returns the status code returned to blocked requests, applying the default if unset.
+func (r *BlockResponse) GetStatusCode() int32 {
+	if r.StatusCode == 0 {
+		return DefaultBlockStatusCode
+	}
+	return r.StatusCode
+}
+
+// AllowsNamespace reports whether Ingresses in a namespace with the given labels may reference
+// the IngressConfig, according to NamespaceSelector.
+func (s *IngressConfigSpec) AllowsNamespace(namespaceLabels map[string]string) (bool, error) {
+	if s.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(s.NamespaceSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(namespaceLabels)), nil
+}
+
+// IngressConfigStatus defines the observed state of IngressConfig.
+type IngressConfigStatus struct {
+	// LastUpdated is the timestamp when the IngressConfig spec was last modified,
+	// triggering a potential reconciliation of associated Ingresses.
+	// This field is updated when the .spec of IngressConfig or the RendererVersion changes.
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// Conditions provide observations of the IngressConfig's state.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// SpecHash is the SHA256 hash of what the rendered configuration depends on: the patterns of the
+	// blocked User-Agents, the response status code and the renderer version.
+	SpecHash string `json:"specHash,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this IngressConfig.
+	// It corresponds to the IngressConfig's generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// RendererVersion is the version of the operator renderer the Ingresses are being configured with.
+	// When an upgrade of the operator changes it, every Ingress is rendered again at a limited rate.
+	// +optional
+	RendererVersion int32 `json:"rendererVersion,omitempty"`
+
+	// ProtectedIngresses is the number of Ingresses referencing this IngressConfig.
+	// +optional
+	ProtectedIngresses int32 `json:"protectedIngresses"`
+
+	// UpdatedIngresses is the number of Ingresses configured with the current SpecHash and RendererVersion.
+	// +optional
+	UpdatedIngresses int32 `json:"updatedIngresses"`
+
+	// FailedIngresses is the number of Ingresses that can't be configured with the current SpecHash.
+	// +optional
+	FailedIngresses int32 `json:"failedIngresses"`
+
+	// Ingresses lists the Ingresses that are not configured with the current SpecHash and RendererVersion yet,
+	// failed ones first.
+	// The list is capped, while the counters above always cover every Ingress.
+	// +kubebuilder:validation:MaxItems=50
+	// +listType=atomic
+	// +optional
+	Ingresses []IngressRolloutStatus `json:"ingresses,omitempty"`
+
+	// RedundantUserAgents lists the BlockedUserAgents entries left out of the generated configuration,
+	// because every User-Agent they match is already blocked by another entry.
+	// +kubebuilder:validation:MaxItems=50
+	// +listType=atomic
+	// +optional
+	RedundantUserAgents []RedundantUserAgent `json:"redundantUserAgents,omitempty"`
+}
+
+// RedundantUserAgent is a BlockedUserAgents entry left out of the generated configuration.
+type RedundantUserAgent struct {
+	// Pattern of the entry left out.
+	Pattern string `json:"pattern"`
+
+	// CoveredBy is the pattern of the entry already blocking every User-Agent matched by Pattern.
+	CoveredBy string `json:"coveredBy"`
+}
+
+// IngressRolloutStatus is the rollout state of a single Ingress referencing the IngressConfig.
+type IngressRolloutStatus struct {
+	// Namespace of the Ingress.
+	Namespace string `json:"namespace"`
+
+	// Name of the Ingress.
+	Name string `json:"name"`
+
+	// ObservedSpecHash is the SpecHash of the configuration currently applied to the Ingress.
+	// Empty if the Ingress was never configured.
+	// +optional
+	ObservedSpecHash string `json:"observedSpecHash,omitempty"`
+
+	// ObservedRendererVersion is the version of the operator renderer the configuration currently applied
+	// to the Ingress was rendered with.
+	// +optional
+	ObservedRendererVersion int32 `json:"observedRendererVersion,omitempty"`
+
+	// LastError describes why the Ingress can't be configured with the current SpecHash.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Ingresses",type="integer",JSONPath=".status.protectedIngresses"
+// +kubebuilder:printcolumn:name="Updated",type="integer",JSONPath=".status.updatedIngresses"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedIngresses"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+// +kubebuilder:printcolumn:name="Last Updated",type="date",JSONPath=".status.lastUpdated"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// IngressConfig is the Schema for the ingressconfigs API.
+type IngressConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IngressConfigSpec   `json:"spec,omitempty"`
+	Status IngressConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IngressConfigList contains a list of IngressConfig.
+type IngressConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IngressConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IngressConfig{}, &IngressConfigList{})
+}
+
+// Standard condition types, understood by kstatus based tools such as Argo CD, Flux and kubectl wait.
+const (
+	ConditionTypeReady       string = "Ready"
+	ConditionTypeProgressing string = "Progressing"
+	ConditionTypeDegraded    string = "Degraded"
+
+	ConditionReasonRolloutInProgress       string = "RolloutInProgress"
+	ConditionReasonRolloutComplete         string = "RolloutComplete"
+	ConditionReasonIngressesFailed         string = "IngressesFailed"
+	ConditionReasonRolloutDeadlineExceeded string = "RolloutDeadlineExceeded"
+	ConditionReasonAsExpected              string = "AsExpected"
+	ConditionReasonDeleting                string = "Deleting"
+	ConditionReasonDeletionBlocked         string = "DeletionBlocked"
+	ConditionReasonSnippetTooLarge         string = "SnippetTooLarge"
+)
+
+// Deprecated: UpdateSucceeded and CleanupSucceeded are kept for compatibility and will be removed in a
+// future release. Use the Ready, Progressing and Degraded conditions instead.
+const (
+	ConditionTypeUpdateSucceeded            string = "UpdateSucceeded"
+	ConditionReasonReconciliationInProgress string = "ReconciliationInProgress"
+	ConditionReasonReconciliationSuccessful string = "ReconciliationSuccessful"
+
+	ConditionTypeCleanupSucceeded    string = "CleanupSucceeded"
+	ConditionReasonCleanupInProgress string = "CleanupInProgress"
+)
+
+// Suspended is True while spec.suspend is set and changes to the IngressConfig aren't rolled out.
+const (
+	ConditionTypeSuspended   string = "Suspended"
+	ConditionReasonSuspended string = "SuspendedBySpec"
+	ConditionReasonResumed   string = "Resumed"
+)
+
+// FightingWithManager is True while the configuration of referencing Ingresses keeps being reverted by another
+// field manager, such as a GitOps tool, and the operator backs off from restoring it.
+const (
+	ConditionTypeFightingWithManager     string = "FightingWithManager"
+	ConditionReasonConfigurationReverted string = "ConfigurationReverted"
+)
@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllowsNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector *metav1.LabelSelector
+		labels   map[string]string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "No selector",
+			selector: nil,
+			labels:   nil,
+			want:     true,
+		},
+		{
+			name:     "Empty selector",
+			selector: &metav1.LabelSelector{},
+			labels:   map[string]string{"team": "web"},
+			want:     true,
+		},
+		{
+			name:     "Matching labels",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+			labels:   map[string]string{"team": "web", "env": "prod"},
+			want:     true,
+		},
+		{
+			name:     "Non matching labels",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+			labels:   map[string]string{"team": "data"},
+			want:     false,
+		},
+		{
+			name: "Invalid selector",
+			selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team", Operator: "Unknown"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := IngressConfigSpec{NamespaceSelector: tt.selector}
+			got, err := spec.AllowsNamespace(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AllowsNamespace() error - got: %v, expected: %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("AllowsNamespace() value - got: %v, expected: %v", got, tt.want)
+			}
+		})
+	}
+}
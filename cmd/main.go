@@ -34,12 +34,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	kubebotblockergithubiov1alpha1 "github.com/GustavoJST/kube-botblocker/api/v1alpha1"
+	kubebotblockergithubiov1beta1 "github.com/GustavoJST/kube-botblocker/api/v1beta1"
 	"github.com/GustavoJST/kube-botblocker/internal/controller"
+	operatormetrics "github.com/GustavoJST/kube-botblocker/internal/metrics"
+	"github.com/GustavoJST/kube-botblocker/internal/migration"
+	webhooknetworkingv1 "github.com/GustavoJST/kube-botblocker/internal/webhook/v1"
+	webhookkubebotblockergithubiov1beta1 "github.com/GustavoJST/kube-botblocker/internal/webhook/v1beta1"
 	"github.com/GustavoJST/kube-botblocker/pkg/environment"
 	// +kubebuilder:scaffold:imports
 )
@@ -53,6 +59,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(kubebotblockergithubiov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kubebotblockergithubiov1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -219,23 +226,64 @@ func main() {
 		os.Exit(1)
 	}
 
+	ingressErrors := controller.NewIngressErrors()
+	fights := controller.NewFightDetector(env.FightDetectionThreshold, env.FightDetectionWindow)
 	if err = (&controller.IngressReconciler{
 		Client:      mgr.GetClient(),
 		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor("ingress-controller"),
+		Errors:      ingressErrors,
+		Fights:      fights,
+		Rerenders:   controller.NewRerenderLimiter(env.RerenderRate),
 		Environment: env,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Ingress")
 		os.Exit(1)
 	}
 	if err = (&controller.IngressConfigReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor("ingressconfig-controller"),
+		Errors:      ingressErrors,
+		Fights:      fights,
+		Environment: env,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "IngressConfig")
 		os.Exit(1)
 	}
+	if env.EnableWebhooks {
+		if err = webhookkubebotblockergithubiov1beta1.SetupIngressConfigWebhookWithManager(mgr, env); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IngressConfig")
+			os.Exit(1)
+		}
+		if err = webhooknetworkingv1.SetupIngressWebhookWithManager(mgr, env); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Ingress")
+			os.Exit(1)
+		}
+	} else if env.EnableConversionWebhook {
+		// The CRD converts v1alpha1 objects through the operator even without admission webhooks
+		if err = webhookkubebotblockergithubiov1beta1.SetupIngressConfigConversionWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create conversion webhook", "webhook", "IngressConfig")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if err := ctrlmetrics.Registry.Register(
+		operatormetrics.NewOrphanCollector(mgr.GetCache(), env.OperatorNamespace),
+	); err != nil {
+		setupLog.Error(err, "unable to register orphaned references collector")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&migration.StorageVersionMigrator{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+	}); err != nil {
+		setupLog.Error(err, "unable to add storage version migrator to manager")
+		os.Exit(1)
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 		if err := mgr.Add(metricsCertWatcher); err != nil {
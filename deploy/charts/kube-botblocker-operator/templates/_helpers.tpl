@@ -60,3 +60,24 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Name of the webhook Service
+*/}}
+{{- define "kube-botblocker-operator.webhookServiceName" -}}
+{{- printf "%s-webhook" (include "kube-botblocker-operator.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Name of the webhook serving Certificate and of its Secret
+*/}}
+{{- define "kube-botblocker-operator.webhookCertificateName" -}}
+{{- printf "%s-serving-cert" (include "kube-botblocker-operator.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Whether the webhook server runs, for the admission webhooks or only for the IngressConfig conversion webhook
+*/}}
+{{- define "kube-botblocker-operator.webhookServerEnabled" -}}
+{{- if or .Values.webhook.enabled .Values.conversionWebhook.enabled }}true{{- end }}
+{{- end }}
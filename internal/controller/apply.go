@@ -0,0 +1,197 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	networkingv1ac "k8s.io/client-go/applyconfigurations/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+)
+
+// FieldManager is the field manager the operator writes Ingresses with.
+const FieldManager = "kube-botblocker"
+
+// managedAnnotations are the Ingress annotations written by the operator with server-side apply.
+var managedAnnotations = []string{
+	annotations.IngressServerSnippet,
+	annotations.IngressConfigSpecHash,
+	annotations.IngressLastError,
+	annotations.IngressSnippetBeforeRepair,
+	annotations.IngressGeneratedSnippet,
+	annotations.IngressOriginalSnippetHash,
+	annotations.IngressOrphaned,
+	annotations.IngressRendererVersion,
+}
+
+// FieldManagerConflictError is returned when the annotations to be changed on an Ingress are owned by other
+// field managers through server-side apply. Taking them over would make both managers overwrite each other.
+type FieldManagerConflictError struct {
+	Managers    []string
+	Annotations []string
+}
+
+func (e *FieldManagerConflictError) Error() string {
+	return fmt.Sprintf("annotations %s are managed by %s using server-side apply. Manual action required",
+		strings.Join(e.Annotations, ", "), strings.Join(e.Managers, ", "))
+}
+
+// applyAnnotations sets the annotations of ingress to desired. The managed annotations that are changed or
+// already owned by the operator are written with server-side apply, so the operator shows as their owner in
+// managedFields. Other annotations are only ever removed, with a merge patch.
+func (r *IngressReconciler) applyAnnotations(ctx context.Context, ingress *networkingv1.Ingress, desired map[string]string) error {
+	current := ingress.GetAnnotations()
+
+	var changed []string
+	for key, value := range current {
+		if desiredValue, ok := desired[key]; !ok || desiredValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range desired {
+		if _, ok := current[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+
+	if managers, fields := applyConflicts(ingress, changed); len(managers) > 0 {
+		return &FieldManagerConflictError{Managers: managers, Annotations: fields}
+	}
+
+	owned := annotationsOwnedBy(ingress, FieldManager)
+	applied := make(map[string]string)
+	for _, key := range managedAnnotations {
+		if value, ok := desired[key]; ok && (owned[key] || slices.Contains(changed, key)) {
+			applied[key] = value
+		}
+	}
+
+	// The resourceVersion keeps the apply from overwriting changes not seen yet or recreating a deleted Ingress
+	data, err := json.Marshal(networkingv1ac.Ingress(ingress.Name, ingress.Namespace).
+		WithResourceVersion(ingress.ResourceVersion).
+		WithAnnotations(applied))
+	if err != nil {
+		return err
+	}
+	// Owners remaining after applyConflicts only use updates, which the operator overwrites like any update would
+	if err := r.Patch(ctx, ingress, client.RawPatch(types.ApplyPatchType, data),
+		client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return err
+	}
+
+	// Annotations left out of the apply are only removed when no other field manager owns them
+	patch := client.MergeFrom(ingress.DeepCopy())
+	ann := ingress.GetAnnotations()
+	removed := false
+	for key := range ann {
+		if _, ok := desired[key]; !ok && slices.Contains(changed, key) {
+			delete(ann, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	ingress.SetAnnotations(ann)
+	return r.Patch(ctx, ingress, patch, client.FieldOwner(FieldManager))
+}
+
+// applyConflicts returns the field managers other than the operator owning any of the given annotations
+// through server-side apply, along with the annotations they own.
+func applyConflicts(obj metav1.Object, keys []string) (managers, fields []string) {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Operation != metav1.ManagedFieldsOperationApply || entry.Subresource != "" ||
+			entry.Manager == FieldManager {
+			continue
+		}
+		owned := ownedAnnotations(entry)
+		for _, key := range keys {
+			if !owned[key] {
+				continue
+			}
+			if !slices.Contains(managers, entry.Manager) {
+				managers = append(managers, entry.Manager)
+			}
+			if !slices.Contains(fields, key) {
+				fields = append(fields, key)
+			}
+		}
+	}
+	return managers, fields
+}
+
+// annotationManagers returns the field managers other than the operator owning the annotation.
+func annotationManagers(obj metav1.Object, key string) []string {
+	var managers []string
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Subresource != "" || entry.Manager == FieldManager || slices.Contains(managers, entry.Manager) {
+			continue
+		}
+		if ownedAnnotations(entry)[key] {
+			managers = append(managers, entry.Manager)
+		}
+	}
+	return managers
+}
+
+// annotationsOwnedBy returns the annotations owned by manager through server-side apply.
+func annotationsOwnedBy(obj metav1.Object, manager string) map[string]bool {
+	owned := make(map[string]bool)
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Operation != metav1.ManagedFieldsOperationApply || entry.Subresource != "" ||
+			entry.Manager != manager {
+			continue
+		}
+		for key := range ownedAnnotations(entry) {
+			owned[key] = true
+		}
+	}
+	return owned
+}
+
+// ownedAnnotations returns the annotations listed in a managedFields entry.
+func ownedAnnotations(entry metav1.ManagedFieldsEntry) map[string]bool {
+	owned := make(map[string]bool)
+	if entry.FieldsV1 == nil {
+		return owned
+	}
+
+	var fields struct {
+		Metadata struct {
+			Annotations map[string]json.RawMessage `json:"f:annotations"`
+		} `json:"f:metadata"`
+	}
+	if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+		return owned
+	}
+	for field := range fields.Metadata.Annotations {
+		if key, ok := strings.CutPrefix(field, "f:"); ok {
+			owned[key] = true
+		}
+	}
+	return owned
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+// maxStuckIngressesInMessage is the maximum number of Ingresses named in the Degraded condition message.
+const maxStuckIngressesInMessage = 10
+
+// setRolloutConditions sets the Ready, Progressing and Degraded conditions of the IngressConfig from the
+// number of referencing Ingresses, how many of them are configured with the current spec hash and how many
+// can't be. Once the rollout deadline is exceeded, the Ingresses listed in .status.ingresses are named in
+// the Degraded condition. It reports whether any of the conditions changed.
+func setRolloutConditions(ingressConfig *v1beta1.IngressConfig, total, updated, failed int32, deadlineExceeded bool) bool {
+	ready := metav1.Condition{
+		Type:    v1beta1.ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1beta1.ConditionReasonRolloutInProgress,
+		Message: "Waiting for all Ingresses to be updated",
+	}
+	progressing := metav1.Condition{
+		Type:    v1beta1.ConditionTypeProgressing,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1beta1.ConditionReasonRolloutInProgress,
+		Message: fmt.Sprintf("%d of %d Ingresses updated", updated, total),
+	}
+	degraded := metav1.Condition{
+		Type:    v1beta1.ConditionTypeDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1beta1.ConditionReasonAsExpected,
+		Message: "No Ingress failed to be updated",
+	}
+
+	if total == updated {
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = v1beta1.ConditionReasonRolloutComplete
+		ready.Message = "Ready for usage"
+		if total > 0 {
+			ready.Message = "All Ingresses successfully reconciled"
+		}
+		progressing.Status = metav1.ConditionFalse
+		progressing.Reason = v1beta1.ConditionReasonRolloutComplete
+	}
+	if failed > 0 {
+		ready.Reason = v1beta1.ConditionReasonIngressesFailed
+		ready.Message = fmt.Sprintf("%d Ingresses can't be updated", failed)
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = v1beta1.ConditionReasonIngressesFailed
+		degraded.Message = ready.Message
+	}
+	if deadlineExceeded && total != updated {
+		ready.Reason = v1beta1.ConditionReasonRolloutDeadlineExceeded
+		ready.Message = fmt.Sprintf("Rollout didn't complete within %ds", *ingressConfig.Spec.RolloutDeadlineSeconds)
+		progressing.Status = metav1.ConditionFalse
+		progressing.Reason = v1beta1.ConditionReasonRolloutDeadlineExceeded
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = v1beta1.ConditionReasonRolloutDeadlineExceeded
+		degraded.Message = stuckIngressesMessage(ingressConfig, total-updated)
+	}
+
+	return setConditions(ingressConfig, ready, progressing, degraded)
+}
+
+// rolloutDeadlineExceeded reports whether the rollout started at .status.lastUpdated should have completed by now.
+func rolloutDeadlineExceeded(ingressConfig *v1beta1.IngressConfig, now time.Time) bool {
+	if ingressConfig.Spec.RolloutDeadlineSeconds == nil || ingressConfig.Status.LastUpdated == nil {
+		return false
+	}
+	deadline := time.Duration(*ingressConfig.Spec.RolloutDeadlineSeconds) * time.Second
+	return now.Sub(ingressConfig.Status.LastUpdated.Time) > deadline
+}
+
+// isRolloutDeadlineExceeded reports whether the IngressConfig is already marked as past its rollout deadline.
+func isRolloutDeadlineExceeded(ingressConfig *v1beta1.IngressConfig) bool {
+	condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, v1beta1.ConditionTypeDegraded)
+	return condition != nil && condition.Status == metav1.ConditionTrue &&
+		condition.Reason == v1beta1.ConditionReasonRolloutDeadlineExceeded
+}
+
+// stuckIngressesMessage names the Ingresses that never received the current spec hash, along with their last error.
+func stuckIngressesMessage(ingressConfig *v1beta1.IngressConfig, outdated int32) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rollout didn't complete within %ds, %d Ingresses not updated: ",
+		*ingressConfig.Spec.RolloutDeadlineSeconds, outdated)
+
+	for i, ing := range ingressConfig.Status.Ingresses {
+		if i == maxStuckIngressesInMessage {
+			fmt.Fprintf(&b, ", and %d more", int(outdated)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s/%s", ing.Namespace, ing.Name)
+		if ing.LastError != "" {
+			fmt.Fprintf(&b, " (%s)", ing.LastError)
+		}
+	}
+	return b.String()
+}
+
+// setFightingCondition sets the FightingWithManager condition of the IngressConfig from the referencing Ingresses
+// whose configuration keeps being reverted, along with the field manager reverting it.
+func setFightingCondition(ingressConfig *v1beta1.IngressConfig, fighting map[types.NamespacedName]string) bool {
+	condition := metav1.Condition{
+		Type:    v1beta1.ConditionTypeFightingWithManager,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1beta1.ConditionReasonAsExpected,
+		Message: "No Ingress configuration is being reverted",
+	}
+	if len(fighting) > 0 {
+		keys := slices.SortedFunc(maps.Keys(fighting), func(a, b types.NamespacedName) int {
+			return strings.Compare(a.String(), b.String())
+		})
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Configuration of %d Ingresses keeps being reverted, backing off: ", len(keys))
+		for i, key := range keys {
+			if i == maxStuckIngressesInMessage {
+				fmt.Fprintf(&b, ", and %d more", len(keys)-i)
+				break
+			}
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s by %s", key, fighting[key])
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1beta1.ConditionReasonConfigurationReverted
+		condition.Message = b.String()
+	}
+	return setConditions(ingressConfig, condition)
+}
+
+// setSuspendedConditions stops reporting progress while the IngressConfig is suspended. Ready is only
+// lowered when the spec was changed after the last rollout, as those changes won't be rolled out.
+func setSuspendedConditions(ingressConfig *v1beta1.IngressConfig) bool {
+	conditions := []metav1.Condition{{
+		Type:    v1beta1.ConditionTypeProgressing,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1beta1.ConditionReasonSuspended,
+		Message: "Rollout to associated Ingresses is suspended",
+	}}
+	if ingressConfig.Generation != ingressConfig.Status.ObservedGeneration {
+		conditions = append(conditions, metav1.Condition{
+			Type:    v1beta1.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1beta1.ConditionReasonSuspended,
+			Message: "Spec changes are not rolled out while suspended",
+		})
+	}
+	return setConditions(ingressConfig, conditions...)
+}
+
+// setDeletingConditions reports the cleanup of the Ingresses as progress of a not ready IngressConfig.
+func setDeletingConditions(ingressConfig *v1beta1.IngressConfig) bool {
+	return setConditions(ingressConfig,
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1beta1.ConditionReasonDeleting,
+			Message: "Cleaning configuration before removal",
+		},
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeProgressing,
+			Status:  metav1.ConditionTrue,
+			Reason:  v1beta1.ConditionReasonCleanupInProgress,
+			Message: "Cleaning configuration before removal",
+		},
+	)
+}
+
+// setSnippetTooLargeConditions reports an IngressConfig whose spec isn't rolled out because the configuration
+// rendered for it exceeds the size budget.
+func setSnippetTooLargeConditions(ingressConfig *v1beta1.IngressConfig, size, budget int) bool {
+	message := fmt.Sprintf("Rendered NGINX configuration is %d bytes, which exceeds the limit of %d bytes. "+
+		"Ingresses keep the configuration of the last accepted spec", size, budget)
+	return setConditions(ingressConfig,
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1beta1.ConditionReasonSnippetTooLarge,
+			Message: message,
+		},
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeProgressing,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1beta1.ConditionReasonSnippetTooLarge,
+			Message: message,
+		},
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  v1beta1.ConditionReasonSnippetTooLarge,
+			Message: message,
+		},
+	)
+}
+
+// setDeletionBlockedConditions reports an IngressConfig with the Block deletion policy whose deletion waits for
+// the Ingresses still referencing it, naming them.
+func setDeletionBlockedConditions(ingressConfig *v1beta1.IngressConfig, ingresses []networkingv1.Ingress) bool {
+	message := fmt.Sprintf("Deletion blocked by deletionPolicy, still referenced by %d Ingresses: %s",
+		len(ingresses), ingressNames(ingresses))
+	return setConditions(ingressConfig,
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1beta1.ConditionReasonDeletionBlocked,
+			Message: message,
+		},
+		metav1.Condition{
+			Type:    v1beta1.ConditionTypeProgressing,
+			Status:  metav1.ConditionFalse,
+			Reason:  v1beta1.ConditionReasonDeletionBlocked,
+			Message: message,
+		},
+	)
+}
+
+// setCleanupWaitingConditions reports the Ingresses the cleanup of an IngressConfig being deleted still waits
+// for, naming them.
+func setCleanupWaitingConditions(ingressConfig *v1beta1.IngressConfig, ingresses []networkingv1.Ingress) bool {
+	return setConditions(ingressConfig, metav1.Condition{
+		Type:   v1beta1.ConditionTypeProgressing,
+		Status: metav1.ConditionTrue,
+		Reason: v1beta1.ConditionReasonCleanupInProgress,
+		Message: fmt.Sprintf("Waiting for configuration to be removed from %d Ingresses: %s",
+			len(ingresses), ingressNames(ingresses)),
+	})
+}
+
+// ingressNames lists the sorted namespaced names of the given Ingresses, up to maxStuckIngressesInMessage.
+func ingressNames(ingresses []networkingv1.Ingress) string {
+	names := make([]string, 0, len(ingresses))
+	for _, ing := range ingresses {
+		names = append(names, ing.Namespace+"/"+ing.Name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	for i, name := range names {
+		if i == maxStuckIngressesInMessage {
+			fmt.Fprintf(&b, ", and %d more", len(names)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// setConditions sets the given conditions with the current generation of the IngressConfig as their
+// observedGeneration. LastTransitionTime is only updated when the status of a condition changes.
+func setConditions(ingressConfig *v1beta1.IngressConfig, conditions ...metav1.Condition) bool {
+	changed := false
+	for _, condition := range conditions {
+		condition.ObservedGeneration = ingressConfig.Generation
+		if meta.SetStatusCondition(&ingressConfig.Status.Conditions, condition) {
+			changed = true
+		}
+	}
+	return changed
+}
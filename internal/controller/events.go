@@ -0,0 +1,43 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+// Reasons of the Events recorded on Ingresses and IngressConfigs.
+const (
+	EventReasonSnippetApplied        = "SnippetApplied"
+	EventReasonSnippetRemoved        = "SnippetRemoved"
+	EventReasonSnippetNotRestored    = "SnippetNotRestored"
+	EventReasonInvalidSnippet        = "InvalidSnippet"
+	EventReasonMarkerMismatch        = "MarkerMismatch"
+	EventReasonMarkersRepaired       = "MarkersRepaired"
+	EventReasonDriftCorrected        = "DriftCorrected"
+	EventReasonFieldManagerConflict  = "FieldManagerConflict"
+	EventReasonFightingWithManager   = "FightingWithManager"
+	EventReasonIngressConfigNotFound = "IngressConfigNotFound"
+	EventReasonOrphaned              = "Orphaned"
+	EventReasonAdopted               = "Adopted"
+
+	EventReasonRolloutStarted          = "RolloutStarted"
+	EventReasonRolloutCompleted        = "RolloutCompleted"
+	EventReasonRolloutDeadlineExceeded = "RolloutDeadlineExceeded"
+	EventReasonSnippetTooLarge         = "SnippetTooLarge"
+	EventReasonCleanupStarted          = "CleanupStarted"
+	EventReasonCleanupWaiting          = "CleanupWaiting"
+	EventReasonCleanupCompleted        = "CleanupCompleted"
+	EventReasonCleanupSkipped          = "CleanupSkipped"
+	EventReasonDeletionBlocked         = "DeletionBlocked"
+)
@@ -0,0 +1,182 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+// maxFightBackoff is the longest time the configuration of a fighting Ingress is left reverted.
+const maxFightBackoff = 6 * time.Hour
+
+// FightDetector detects Ingresses whose configuration keeps being reverted by another field manager, such as
+// a GitOps tool self-healing them, so the operator backs off instead of rewriting it forever. An Ingress is
+// fighting once its configuration was rewritten threshold times within window. Rewrites are then held back for
+// window, doubling each time the fight starts again right after. It is shared by both reconcilers.
+// A nil *FightDetector is valid and detects nothing.
+type FightDetector struct {
+	threshold int
+	window    time.Duration
+
+	mu     sync.Mutex
+	fights map[types.NamespacedName]*fight
+	events chan event.GenericEvent
+}
+
+type fight struct {
+	ingressConfig types.NamespacedName
+	manager       string
+	rewrites      []time.Time
+	backoff       time.Duration
+	backoffUntil  time.Time
+}
+
+// NewFightDetector returns a FightDetector, or nil if threshold is lower than 2 or window is 0.
+func NewFightDetector(threshold int, window time.Duration) *FightDetector {
+	if threshold < 2 || window <= 0 {
+		return nil
+	}
+	return &FightDetector{
+		threshold: threshold,
+		window:    window,
+		fights:    make(map[types.NamespacedName]*fight),
+		events:    make(chan event.GenericEvent, 100),
+	}
+}
+
+// Record records a rewrite of the configuration of the Ingress after it was reverted by manager.
+// It reports whether the Ingress started fighting with this rewrite.
+func (d *FightDetector) Record(key, ingressConfig types.NamespacedName, manager string, now time.Time) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, ok := d.fights[key]
+	if !ok {
+		f = &fight{}
+		d.fights[key] = f
+	}
+	f.ingressConfig = ingressConfig
+	f.manager = manager
+
+	rewrites := f.rewrites[:0]
+	for _, rewrite := range f.rewrites {
+		if now.Sub(rewrite) < d.window {
+			rewrites = append(rewrites, rewrite)
+		}
+	}
+	f.rewrites = append(rewrites, now)
+	if !d.recentlyFought(f, now) {
+		f.backoff = 0
+	}
+
+	// Right after a backoff, a single revert of the rewrite is enough to start fighting again
+	threshold := d.threshold
+	if f.backoff > 0 {
+		threshold = 2
+	}
+	if len(f.rewrites) < threshold {
+		return false
+	}
+
+	f.backoff = min(max(2*f.backoff, d.window), maxFightBackoff)
+	f.backoffUntil = now.Add(f.backoff)
+	f.rewrites = nil
+
+	obj := &v1beta1.IngressConfig{}
+	obj.SetNamespace(ingressConfig.Namespace)
+	obj.SetName(ingressConfig.Name)
+	select {
+	case d.events <- event.GenericEvent{Object: obj}:
+	default:
+		// The IngressConfig is still reported on its next reconciliation
+	}
+	return true
+}
+
+// BackingOff returns until when rewrites of the configuration of the Ingress are held back, if they are.
+func (d *FightDetector) BackingOff(key types.NamespacedName, now time.Time) (time.Time, bool) {
+	if d == nil {
+		return time.Time{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, ok := d.fights[key]
+	if !ok || !now.Before(f.backoffUntil) {
+		return time.Time{}, false
+	}
+	return f.backoffUntil, true
+}
+
+// Fighting returns the Ingresses referencing the IngressConfig that fought recently, with the field manager
+// reverting their configuration. An Ingress stops being reported a window after its last backoff ended.
+func (d *FightDetector) Fighting(ingressConfig types.NamespacedName, now time.Time) map[types.NamespacedName]string {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var fighting map[types.NamespacedName]string
+	for key, f := range d.fights {
+		if f.ingressConfig != ingressConfig {
+			continue
+		}
+		if !d.recentlyFought(f, now) {
+			if len(f.rewrites) == 0 || now.Sub(f.rewrites[len(f.rewrites)-1]) >= d.window {
+				delete(d.fights, key)
+			}
+			continue
+		}
+		if fighting == nil {
+			fighting = make(map[types.NamespacedName]string)
+		}
+		fighting[key] = f.manager
+	}
+	return fighting
+}
+
+// Forget forgets the rewrites of the Ingress.
+func (d *FightDetector) Forget(key types.NamespacedName) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.fights, key)
+}
+
+// Events returns the channel the IngressConfigs of Ingresses starting to fight are sent to, or nil.
+func (d *FightDetector) Events() <-chan event.GenericEvent {
+	if d == nil {
+		return nil
+	}
+	return d.events
+}
+
+func (d *FightDetector) recentlyFought(f *fight, now time.Time) bool {
+	return f.backoff > 0 && now.Before(f.backoffUntil.Add(d.window))
+}
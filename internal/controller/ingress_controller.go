@@ -16,16 +16,22 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"regexp"
+	"maps"
+	"strconv"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,24 +39,51 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	"github.com/GustavoJST/kube-botblocker/api/v1alpha1"
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/internal/metrics"
 	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
 	"github.com/GustavoJST/kube-botblocker/pkg/environment"
 	"github.com/GustavoJST/kube-botblocker/pkg/indexer"
+	"github.com/GustavoJST/kube-botblocker/pkg/nginx"
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
 )
 
 // IngressReconciler reconciles a Ingress object
 type IngressReconciler struct {
 	client.Client
 	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
+	Errors      *IngressErrors
+	Fights      *FightDetector
+	Rerenders   *RerenderLimiter
 	Environment *environment.OperatorEnv
 }
 
+// ingressChanges are the changes made to the annotations of an Ingress by a reconciliation, along with what
+// to report once they are applied.
+type ingressChanges struct {
+	// ann is a copy of the Ingress annotations, so changes can be compared with the current ones when applied
+	ann     map[string]string
+	changed bool
+	// repairedWith is the policy the server-snippet markers were repaired with, if they were
+	repairedWith v1beta1.MarkerRepairPolicy
+	// driftCorrected is whether changes made to the generated configuration by revertedBy were reverted
+	driftCorrected bool
+	revertedBy     string
+	// notRestored is the annotation whose original snippet couldn't be restored exactly, if any
+	notRestored string
+	adopted     bool
+	// removed is whether a generated configuration block was stripped, as opposed to only leftover annotations
+	removed bool
+}
+
 // +kubebuilder:rbac:groups=kube-botblocker.github.io,resources=ingressconfigs,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;patch;update;watch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
@@ -59,90 +92,582 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err := r.Get(ctx, req.NamespacedName, &ingress); err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("Ingress not found")
+			r.Errors.Clear(req.NamespacedName)
+			r.Fights.Forget(req.NamespacedName)
+			r.Rerenders.Forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "Error fetching Ingress")
 		return ctrl.Result{}, err
 	}
 
-	ann := ingress.GetAnnotations()
+	ann := maps.Clone(ingress.GetAnnotations())
 	if ann == nil {
 		ann = make(map[string]string)
 	}
+	changes := &ingressChanges{ann: ann}
 
-	ingressConfigName, protected := ann[annotations.IngressConfigNameAnnotation]
-	changed := false
+	if ann[annotations.IngressPaused] == "true" {
+		log.Info("Ingress is paused; skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
 
-	if protected {
-		var ingressConfig v1alpha1.IngressConfig
-		key := types.NamespacedName{Namespace: r.Environment.OperatorNamespace, Name: ingressConfigName}
-		if err := r.Get(ctx, key, &ingressConfig); err != nil {
-			if apierrors.IsNotFound(err) {
-				log.Info("Specified IngressConfig not found in operator namespace; skipping update", "ingressConfigName", ingressConfigName)
-				return ctrl.Result{}, nil
+	// An empty reference is handled as no reference at all, removing any leftover configuration
+	ingressConfigName := ann[annotations.IngressConfigNameAnnotation]
+	ingressConfigKey := types.NamespacedName{Namespace: r.Environment.OperatorNamespace, Name: ingressConfigName}
+
+	if done, err := r.removeServerSnippetLeftovers(ctx, &ingress, ingressConfigName, changes); done || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var ingressConfig *v1beta1.IngressConfig
+	keepReference := false
+	if ingressConfigName != "" {
+		ingressConfig = &v1beta1.IngressConfig{}
+		if err := r.Get(ctx, ingressConfigKey, ingressConfig); err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Error(err, "Error fetching IngressConfig", "ingressConfigName", ingressConfigName)
+				return ctrl.Result{}, err
+			}
+			if r.Environment.RetainOrphanedConfig {
+				return ctrl.Result{}, r.orphan(ctx, &ingress, ann, ingressConfigName)
 			}
-			log.Error(err, "Error fetching IngressConfig", "ingressConfigName", ingressConfigName)
+			// The configuration is removed, keeping the reference so an IngressConfig recreated with the
+			// same name configures the Ingress again
+			log.Info("Specified IngressConfig not found in operator namespace; removing configuration",
+				"ingressConfigName", ingressConfigName)
+			r.Recorder.Eventf(&ingress, corev1.EventTypeWarning, EventReasonIngressConfigNotFound,
+				"IngressConfig %s not found in namespace %s", ingressConfigName, r.Environment.OperatorNamespace)
+			ingressConfig = nil
+			keepReference = true
+		}
+	}
+	protected := ingressConfig != nil
+
+	if protected {
+		if skip, err := r.skipUpdate(ctx, &ingress, ingressConfig); skip || err != nil {
 			return ctrl.Result{}, err
 		}
+		if result, done, err := r.configure(ctx, req, &ingress, ingressConfig, changes); done || err != nil {
+			return result, err
+		}
+	} else if done, err := r.unconfigure(ctx, &ingress, keepReference, changes); done || err != nil {
+		return ctrl.Result{}, err
+	}
 
-		if ingressConfig.Status.SpecHash != ann[annotations.IngressConfigSpecHash] {
-			desiredSnippet := buildNginxConfig(ingressConfig.Spec.BlockedUserAgents)
-			currentSnippet := ann[annotations.IngressServerSnippet]
-			updatedSnippet, err := updateServerSnippet(currentSnippet, desiredSnippet)
-			if err != nil {
-				log.Error(err, "Failed to create updated server-snippet annotation configuration")
+	if changes.changed {
+		if protected {
+			if done, err := r.validateChanges(ctx, req, &ingress, changes); done || err != nil {
 				return ctrl.Result{}, err
 			}
+		}
+		if done, err := r.applyChanges(ctx, req, &ingress, changes); done || err != nil {
+			return ctrl.Result{}, err
+		}
+		r.reportChanges(ctx, req, &ingress, ingressConfigKey, protected, changes)
+	}
+
+	if protected && r.Environment.DriftCheckInterval > 0 {
+		// Check again later for changes to the generated configuration
+		return ctrl.Result{RequeueAfter: r.Environment.DriftCheckInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
 
-			ann[annotations.IngressConfigSpecHash] = ingressConfig.Status.SpecHash
-			ann[annotations.IngressServerSnippet] = updatedSnippet
-			changed = true
+// removeServerSnippetLeftovers removes the configuration written to the server-snippet annotation before the
+// operator was switched to the Annotation mode, whether the Ingress is still protected or not. It reports
+// whether the reconciliation ends there, when the configuration can't be removed.
+func (r *IngressReconciler) removeServerSnippetLeftovers(
+	ctx context.Context,
+	ingress *networkingv1.Ingress,
+	ingressConfigName string,
+	changes *ingressChanges,
+) (bool, error) {
+	log := log.FromContext(ctx)
+	ann := changes.ann
+
+	serverSnippet := ann[annotations.IngressServerSnippet]
+	if r.Environment.SnippetAnnotation() == annotations.IngressServerSnippet || !snippet.HasMarkers(serverSnippet) {
+		return false, nil
+	}
+
+	cleaned, err := snippet.Update(serverSnippet, "")
+	if err != nil {
+		log.Error(err, "Failed cleaning configuration left in Ingress server-snippet annotation")
+		recorded, recordErr := r.recordLastError(ctx, ingress, err)
+		if recordErr != nil {
+			return true, recordErr
+		}
+		if recorded {
+			metrics.MarkerMismatchErrors.WithLabelValues(ingressConfigName).Inc()
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonMarkerMismatch, err.Error())
+		}
+		return true, nil
+	}
 
+	// The original snippet hash can only have been recorded for the server-snippet annotation
+	if originalHash, ok := ann[annotations.IngressOriginalSnippetHash]; ok {
+		if restored, exact := snippet.Restore(serverSnippet, originalHash); exact {
+			cleaned = restored
+		} else {
+			changes.notRestored = annotations.IngressServerSnippet
 		}
+		delete(ann, annotations.IngressOriginalSnippetHash)
+	}
+
+	if cleaned == "" {
+		delete(ann, annotations.IngressServerSnippet)
 	} else {
-		// Ingress is not protected or is being cleaned up
-		// Remove all operator-added configuration
-		if currentSnippet, ok := ann[annotations.IngressServerSnippet]; ok {
-			cleaned, err := updateServerSnippet(currentSnippet, "")
-			if err != nil {
-				log.Error(err, "Failed cleaning Ingress server-snippet annotation")
-				return ctrl.Result{}, err
+		ann[annotations.IngressServerSnippet] = cleaned
+	}
+	log.Info("Removing configuration left in server-snippet annotation by the ServerSnippet mode")
+	changes.changed = true
+	changes.removed = true
+	return false, nil
+}
+
+// skipUpdate reports whether the configuration of a protected Ingress is left as is, because its IngressConfig
+// is suspended or doesn't allow the namespace of the Ingress.
+func (r *IngressReconciler) skipUpdate(
+	ctx context.Context,
+	ingress *networkingv1.Ingress,
+	ingressConfig *v1beta1.IngressConfig,
+) (bool, error) {
+	log := log.FromContext(ctx)
+
+	if ingressConfig.Spec.Suspend {
+		log.Info("IngressConfig is suspended; skipping update", "ingressConfigName", ingressConfig.Name)
+		return true, nil
+	}
+
+	if ingressConfig.Spec.NamespaceSelector == nil || r.Environment.CurrentNamespaceOnly {
+		return false, nil
+	}
+	var namespace corev1.Namespace
+	if err := r.Get(ctx, types.NamespacedName{Name: ingress.Namespace}, &namespace); err != nil {
+		log.Error(err, "Error fetching Ingress namespace")
+		return true, err
+	}
+	allowed, err := ingressConfig.Spec.AllowsNamespace(namespace.Labels)
+	if err != nil {
+		log.Error(err, "Invalid IngressConfig namespaceSelector", "ingressConfigName", ingressConfig.Name)
+		return true, nil
+	}
+	if !allowed {
+		log.Info("Ingress namespace not allowed by the IngressConfig namespaceSelector; skipping update",
+			"ingressConfigName", ingressConfig.Name)
+		return true, nil
+	}
+	return false, nil
+}
+
+// configure adds the configuration of ingressConfig to the changes made to a protected Ingress. It is rendered
+// again when the Ingress has an outdated spec hash or renderer version, and restored when it was edited. It
+// reports whether the reconciliation ends there, with the returned result.
+func (r *IngressReconciler) configure(
+	ctx context.Context,
+	req ctrl.Request,
+	ingress *networkingv1.Ingress,
+	ingressConfig *v1beta1.IngressConfig,
+	changes *ingressChanges,
+) (ctrl.Result, bool, error) {
+	log := log.FromContext(ctx)
+	ann := changes.ann
+
+	desiredSnippet := snippet.Build(ingressConfig.Spec.Patterns(), ingressConfig.Spec.Response.GetStatusCode())
+
+	// The IngressConfig controller accepts a new spec or renderer version, or refuses it when the rendered
+	// configuration exceeds the size budget. Until then, the Ingress keeps its configuration.
+	accepted := ingressConfig.Status.ObservedGeneration == ingressConfig.Generation &&
+		ingressConfig.Status.RendererVersion == snippet.RendererVersion
+
+	// Configuration rendered by an earlier release is only rendered again at a limited rate. The renderer
+	// version is part of the spec hash, so these Ingresses have an outdated spec hash as well.
+	_, configured := ann[annotations.IngressConfigSpecHash]
+	rerender := configured && renderedWith(ann) != snippet.RendererVersion
+	if rerender && accepted {
+		if delay := r.Rerenders.Delay(req.NamespacedName, time.Now()); delay > 0 {
+			log.Info("Waiting to render configuration again with the current renderer", "after", delay)
+			return ctrl.Result{RequeueAfter: delay}, true, nil
+		}
+	}
+
+	outdated := ingressConfig.Status.SpecHash != ann[annotations.IngressConfigSpecHash] || rerender
+	switch {
+	case outdated && !accepted:
+		log.Info("IngressConfig spec not accepted yet; leaving configuration unchanged",
+			"ingressConfigName", ingressConfig.Name)
+	case outdated:
+		if done, err := r.render(ctx, ingress, ingressConfig, desiredSnippet, changes); done || err != nil {
+			return ctrl.Result{}, true, err
+		}
+	case accepted:
+		// Skipped while a new spec of the IngressConfig is waiting for its rollout to start
+		if result, done, err := r.correctDrift(ctx, req, ingress, desiredSnippet, changes); done || err != nil {
+			return result, true, err
+		}
+	}
+
+	if _, exists := ann[annotations.IngressLastError]; exists {
+		delete(ann, annotations.IngressLastError)
+		changes.changed = true
+	}
+
+	if _, exists := ann[annotations.IngressOrphaned]; exists {
+		delete(ann, annotations.IngressOrphaned)
+		changes.changed = true
+		changes.adopted = true
+	}
+	return ctrl.Result{}, false, nil
+}
+
+// render replaces the configuration block of the Ingress with desiredSnippet, repairing the server-snippet
+// markers as allowed by the marker repair policy of the IngressConfig. It reports whether the reconciliation
+// ends there, when the markers can't be repaired.
+func (r *IngressReconciler) render(
+	ctx context.Context,
+	ingress *networkingv1.Ingress,
+	ingressConfig *v1beta1.IngressConfig,
+	desiredSnippet string,
+	changes *ingressChanges,
+) (bool, error) {
+	log := log.FromContext(ctx)
+	ann := changes.ann
+	snippetAnnotation := r.Environment.SnippetAnnotation()
+	currentSnippet := ann[snippetAnnotation]
+
+	updatedSnippet, repaired, err := snippet.Apply(currentSnippet, desiredSnippet, ingressConfig.Spec.MarkerRepairPolicy)
+	if err != nil {
+		log.Error(err, "Failed to create updated server-snippet annotation configuration")
+		recorded, recordErr := r.recordLastError(ctx, ingress, err)
+		if recordErr != nil {
+			return true, recordErr
+		}
+		if recorded {
+			metrics.MarkerMismatchErrors.WithLabelValues(ingressConfig.Name).Inc()
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonMarkerMismatch, err.Error())
+			r.Recorder.Eventf(ingressConfig, corev1.EventTypeWarning, EventReasonMarkerMismatch,
+				"Ingress %s/%s can't be updated: %s", ingress.Namespace, ingress.Name, err.Error())
+		}
+		return true, nil
+	}
+
+	if repaired {
+		// Keep the content before the repair for audit
+		ann[annotations.IngressSnippetBeforeRepair] = currentSnippet
+		changes.repairedWith = ingressConfig.Spec.MarkerRepairPolicy
+	}
+
+	recordOriginalSnippet(ann, currentSnippet)
+	ann[annotations.IngressConfigSpecHash] = ingressConfig.Status.SpecHash
+	ann[annotations.IngressRendererVersion] = strconv.Itoa(int(snippet.RendererVersion))
+	ann[snippetAnnotation] = updatedSnippet
+	changes.changed = true
+	return false, nil
+}
+
+// correctDrift restores the configuration block of the Ingress when it was edited without touching the spec
+// hash, unless the operator is backing off from a field manager that keeps reverting it. It reports whether
+// the reconciliation ends there, with the returned result.
+func (r *IngressReconciler) correctDrift(
+	ctx context.Context,
+	req ctrl.Request,
+	ingress *networkingv1.Ingress,
+	desiredSnippet string,
+	changes *ingressChanges,
+) (ctrl.Result, bool, error) {
+	log := log.FromContext(ctx)
+	ann := changes.ann
+	snippetAnnotation := r.Environment.SnippetAnnotation()
+	currentSnippet := ann[snippetAnnotation]
+
+	if block, ok := snippet.Extract(currentSnippet); !ok || block == desiredSnippet {
+		return ctrl.Result{}, false, nil
+	}
+	if until, backingOff := r.Fights.BackingOff(req.NamespacedName, time.Now()); backingOff {
+		log.Info("Configuration keeps being reverted; backing off", "until", until)
+		return ctrl.Result{RequeueAfter: time.Until(until)}, true, nil
+	}
+	updatedSnippet, err := snippet.Update(currentSnippet, desiredSnippet)
+	if err != nil {
+		log.Error(err, "Failed to correct drift of server-snippet annotation configuration")
+		return ctrl.Result{}, true, err
+	}
+	log.Info("Drift detected in server-snippet; restoring generated configuration")
+	recordOriginalSnippet(ann, currentSnippet)
+	ann[snippetAnnotation] = updatedSnippet
+	changes.changed = true
+	changes.driftCorrected = true
+	changes.revertedBy = strings.Join(annotationManagers(ingress, snippetAnnotation), ", ")
+	return ctrl.Result{}, false, nil
+}
+
+// unconfigure removes all operator-added configuration from an Ingress that is not protected, or whose
+// IngressConfig doesn't exist anymore, in which case keepReference keeps the reference to it. It reports
+// whether the reconciliation ends there, when the configuration can't be removed.
+func (r *IngressReconciler) unconfigure(
+	ctx context.Context,
+	ingress *networkingv1.Ingress,
+	keepReference bool,
+	changes *ingressChanges,
+) (bool, error) {
+	log := log.FromContext(ctx)
+	ann := changes.ann
+	snippetAnnotation := r.Environment.SnippetAnnotation()
+
+	if currentSnippet, ok := ann[snippetAnnotation]; ok {
+		cleaned, err := snippet.Update(currentSnippet, "")
+		if err != nil {
+			log.Error(err, "Failed cleaning Ingress server-snippet annotation")
+			recorded, recordErr := r.recordLastError(ctx, ingress, err)
+			if recordErr != nil {
+				return true, recordErr
+			}
+			if recorded {
+				metrics.MarkerMismatchErrors.WithLabelValues("").Inc()
+				r.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonMarkerMismatch, err.Error())
 			}
+			return true, nil
+		}
 
-			if cleaned == "" {
-				delete(ann, annotations.IngressServerSnippet)
+		if originalHash, ok := ann[annotations.IngressOriginalSnippetHash]; ok {
+			if restored, exact := snippet.Restore(currentSnippet, originalHash); exact {
+				cleaned = restored
 			} else {
-				ann[annotations.IngressServerSnippet] = cleaned
+				changes.notRestored = snippetAnnotation
 			}
+		}
 
-			changed = true
+		if cleaned == "" {
+			delete(ann, snippetAnnotation)
+		} else {
+			ann[snippetAnnotation] = cleaned
 		}
 
-		if _, exists := ann[annotations.IngressConfigNameAnnotation]; exists {
-			delete(ann, annotations.IngressConfigNameAnnotation)
-			changed = true
+		changes.changed = true
+		changes.removed = changes.removed || snippet.HasMarkers(currentSnippet)
+	}
+
+	leftovers := []string{
+		annotations.IngressConfigSpecHash,
+		annotations.IngressRendererVersion,
+		annotations.IngressLastError,
+		annotations.IngressOriginalSnippetHash,
+		annotations.IngressOrphaned,
+	}
+	if !keepReference {
+		leftovers = append(leftovers, annotations.IngressConfigNameAnnotation)
+	}
+	for _, key := range leftovers {
+		if _, exists := ann[key]; exists {
+			delete(ann, key)
+			changes.changed = true
 		}
+	}
+
+	if changes.changed {
+		log.Info("Cleaning Ingress")
+	}
+	return false, nil
+}
 
-		if _, exists := ann[annotations.IngressConfigSpecHash]; exists {
-			delete(ann, annotations.IngressConfigSpecHash)
-			changed = true
+// validateChanges checks the annotations of a protected Ingress can be written before applying them. Errors
+// are reported on the Ingress instead of having every update rejected by the API server, or the configuration
+// rejected by ingress-nginx once written. It reports whether the reconciliation ends there.
+func (r *IngressReconciler) validateChanges(
+	ctx context.Context,
+	req ctrl.Request,
+	ingress *networkingv1.Ingress,
+	changes *ingressChanges,
+) (bool, error) {
+	log := log.FromContext(ctx)
+
+	if err := apivalidation.ValidateAnnotationsSize(changes.ann); err != nil {
+		err = fmt.Errorf("configuration doesn't fit in the Ingress annotations: %w", err)
+		log.Error(err, "Failed to add configuration to Ingress")
+		r.Errors.Set(req.NamespacedName, err)
+		recorded, recordErr := r.recordLastError(ctx, ingress, err)
+		if recordErr != nil {
+			return true, recordErr
+		}
+		if recorded {
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonSnippetTooLarge, err.Error())
 		}
+		return true, nil
+	}
 
-		if changed {
-			log.Info("Cleaning Ingress")
+	snippetAnnotation := r.Environment.SnippetAnnotation()
+	updatedSnippet := changes.ann[snippetAnnotation]
+	if updatedSnippet == ingress.GetAnnotations()[snippetAnnotation] {
+		return false, nil
+	}
+	if err := nginx.Validate(updatedSnippet); err != nil {
+		err = fmt.Errorf("server-snippet is not valid NGINX configuration: %w", err)
+		log.Error(err, "Failed to add configuration to Ingress")
+		r.Errors.Set(req.NamespacedName, err)
+		recorded, recordErr := r.recordLastError(ctx, ingress, err)
+		if recordErr != nil {
+			return true, recordErr
 		}
+		if recorded {
+			r.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonInvalidSnippet, err.Error())
+		}
+		return true, nil
 	}
+	return false, nil
+}
 
-	if changed {
-		ingress.SetAnnotations(ann)
-		if err := r.Update(ctx, &ingress); err != nil {
-			log.Error(err, "Failed updating Ingress")
-			return ctrl.Result{}, err
+// applyChanges writes the changed annotations to the Ingress. A conflict with other field managers is reported
+// on the Ingress instead of being retried. It reports whether the reconciliation ends there.
+func (r *IngressReconciler) applyChanges(
+	ctx context.Context,
+	req ctrl.Request,
+	ingress *networkingv1.Ingress,
+	changes *ingressChanges,
+) (bool, error) {
+	log := log.FromContext(ctx)
+
+	if err := r.applyAnnotations(ctx, ingress, changes.ann); err != nil {
+		var conflictErr *FieldManagerConflictError
+		if errors.As(err, &conflictErr) {
+			log.Error(err, "Ingress annotations are managed by other field managers")
+			r.Errors.Set(req.NamespacedName, err)
+			recorded, recordErr := r.recordLastError(ctx, ingress, err)
+			if recordErr != nil {
+				return true, recordErr
+			}
+			if recorded {
+				r.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonFieldManagerConflict, err.Error())
+			}
+			return true, nil
 		}
-		log.Info("Ingress updated successfully")
+		log.Error(err, "Failed updating Ingress")
+		r.Errors.Set(req.NamespacedName, err)
+		return true, err
 	}
+	log.Info("Ingress updated successfully")
+	r.Errors.Clear(req.NamespacedName)
+	return false, nil
+}
 
-	return ctrl.Result{}, nil
+// reportChanges records the Events and metrics for the changes applied to an Ingress, and the fights with the
+// field managers reverting its configuration.
+func (r *IngressReconciler) reportChanges(
+	ctx context.Context,
+	req ctrl.Request,
+	ingress *networkingv1.Ingress,
+	ingressConfigKey types.NamespacedName,
+	protected bool,
+	changes *ingressChanges,
+) {
+	log := log.FromContext(ctx)
+	ingressConfigName := ingressConfigKey.Name
+
+	if changes.driftCorrected {
+		metrics.DriftCorrections.WithLabelValues(ingressConfigName).Inc()
+		r.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonDriftCorrected,
+			"Restored the configuration of IngressConfig %s that was changed outside of the operator", ingressConfigName)
+		revertedBy := changes.revertedBy
+		if revertedBy == "" {
+			revertedBy = "an unknown field manager"
+		}
+		if r.Fights.Record(req.NamespacedName, ingressConfigKey, revertedBy, time.Now()) {
+			log.Info("Configuration keeps being reverted; backing off", "fieldManager", revertedBy)
+			r.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonFightingWithManager,
+				"Configuration keeps being reverted by %s. The operator stops restoring it for a while", revertedBy)
+		}
+	}
+	if changes.repairedWith != "" {
+		log.Info("Repaired server-snippet markers", "policy", changes.repairedWith)
+		r.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonMarkersRepaired,
+			"Repaired server-snippet markers using policy %s. The previous server-snippet is saved in the %s annotation",
+			changes.repairedWith, annotations.IngressSnippetBeforeRepair)
+	}
+	if changes.adopted {
+		log.Info("Orphaned Ingress adopted", "ingressConfigName", ingressConfigName)
+		r.Recorder.Eventf(ingress, corev1.EventTypeNormal, EventReasonAdopted,
+			"Adopted by IngressConfig %s", ingressConfigName)
+	}
+	if protected {
+		r.Recorder.Eventf(ingress, corev1.EventTypeNormal, EventReasonSnippetApplied,
+			"Applied configuration of IngressConfig %s", ingressConfigName)
+	} else if changes.removed {
+		r.Recorder.Event(ingress, corev1.EventTypeNormal, EventReasonSnippetRemoved,
+			"Removed kube-botblocker configuration")
+	}
+	if changes.notRestored != "" {
+		log.Info("Snippet changed since the configuration was added; original snippet not restored exactly")
+		r.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonSnippetNotRestored,
+			"The %s annotation was changed since the configuration was added, so it couldn't be restored exactly. "+
+				"Only the configuration was removed, along with the surrounding whitespace", changes.notRestored)
+	}
+}
+
+// orphan marks an Ingress configured by an IngressConfig that no longer exists as orphaned. Its configuration
+// is kept as last rendered, so a missing IngressConfig doesn't leave it unprotected, until an IngressConfig with
+// the same name is created and adopts it again.
+func (r *IngressReconciler) orphan(
+	ctx context.Context,
+	ingress *networkingv1.Ingress,
+	ann map[string]string,
+	ingressConfigName string,
+) error {
+	log := log.FromContext(ctx)
+
+	if _, configured := ann[annotations.IngressConfigSpecHash]; !configured {
+		log.Info("Specified IngressConfig not found in operator namespace; skipping update", "ingressConfigName", ingressConfigName)
+		r.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonIngressConfigNotFound,
+			"IngressConfig %s not found in namespace %s", ingressConfigName, r.Environment.OperatorNamespace)
+		return nil
+	}
+	if ann[annotations.IngressOrphaned] == "true" {
+		log.Info("Ingress is orphaned; keeping its last configuration", "ingressConfigName", ingressConfigName)
+		return nil
+	}
+
+	ann[annotations.IngressOrphaned] = "true"
+	if err := r.applyAnnotations(ctx, ingress, ann); err != nil {
+		log.Error(err, "Failed marking Ingress as orphaned")
+		return err
+	}
+	log.Info("IngressConfig not found; Ingress marked as orphaned", "ingressConfigName", ingressConfigName)
+	r.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonOrphaned,
+		"IngressConfig %s not found in namespace %s. Its last configuration is kept until it is recreated",
+		ingressConfigName, r.Environment.OperatorNamespace)
+	return nil
+}
+
+// recordOriginalSnippet keeps the hash of the snippet annotation of the Ingress before the configuration is
+// added to it, so it can be restored exactly once the configuration is removed.
+func recordOriginalSnippet(ann map[string]string, currentSnippet string) {
+	if block, ok := snippet.Extract(currentSnippet); !ok || block != "" {
+		return
+	}
+	if currentSnippet == "" {
+		delete(ann, annotations.IngressOriginalSnippetHash)
+		return
+	}
+	ann[annotations.IngressOriginalSnippetHash] = snippet.Hash(currentSnippet)
+}
+
+// recordLastError records on the Ingress an error that can only be solved by manual action. Such errors are
+// not retried: the Ingress is reconciled again once its server-snippet is changed or the error is removed.
+// It reports whether the error was not already recorded.
+func (r *IngressReconciler) recordLastError(ctx context.Context, ingress *networkingv1.Ingress, err error) (bool, error) {
+	if ingress.GetAnnotations()[annotations.IngressLastError] == err.Error() {
+		return false, nil
+	}
+
+	ann := maps.Clone(ingress.GetAnnotations())
+	if ann == nil {
+		ann = make(map[string]string)
+	}
+	ann[annotations.IngressLastError] = err.Error()
+	if err := r.applyAnnotations(ctx, ingress, ann); err != nil {
+		log.FromContext(ctx).Error(err, "Failed recording last error on Ingress")
+		return false, err
+	}
+	return true, nil
 }
 
 func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -194,73 +719,30 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&networkingv1.Ingress{}, builder.WithPredicates(ingressPredicate())).
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1.Ingress{}, builder.WithPredicates(ingressPredicate(r.Environment.SnippetAnnotation()))).
 		Watches(
-			&v1alpha1.IngressConfig{},
+			&v1beta1.IngressConfig{},
 			handler.EnqueueRequestsFromMapFunc(r.ReconcileFanOut),
 			builder.WithPredicates(ingressConfigPredicate()),
-		).
-		Named("ingress").
-		Complete(r)
-}
-
-var (
-	startMarker = fmt.Sprintf("# %s operator: Configuration start\n", v1alpha1.GroupVersion.Group)
-	endMarker   = fmt.Sprintf("# %s operator: Configuration end", v1alpha1.GroupVersion.Group)
-)
-
-func updateServerSnippet(currentConf, updatedConf string) (string, error) {
-	startMarkerCount := strings.Count(currentConf, startMarker)
-	endMarkerCount := strings.Count(currentConf, endMarker)
-
-	if startMarkerCount != endMarkerCount || startMarkerCount > 1 && endMarkerCount > 1 {
-		return "", fmt.Errorf(
-			"mismatched or wrong number of start and end markers for kube-botblocker config. "+
-				"Expected 1 start and end markers, got %d start and %d end markers. Manual action required",
-			startMarkerCount, endMarkerCount,
 		)
-	}
-
-	pattern := regexp.MustCompile("(?sm)^" + regexp.QuoteMeta(startMarker) + ".*?" + regexp.QuoteMeta(endMarker) + "$")
-
-	if updatedConf == "" {
-		result := pattern.ReplaceAllLiteralString(currentConf, "")
-		result = strings.TrimSpace(result)
-		return result, nil
-	}
-
-	// Add updatedConf if currentConf is empty or doesn't have a valid kube-botblocker config
-	// with start and end markers
-	if !pattern.MatchString(currentConf) {
-		if currentConf == "" {
-			return updatedConf, nil
+	if r.Environment.DriftCheckInterval > 0 {
+		// Leftover configuration is swept as often as protected Ingresses are checked for drift
+		sweeper := newLeftoverSweeper(mgr.GetClient(), r.Environment.DriftCheckInterval)
+		if err := mgr.Add(sweeper); err != nil {
+			return err
 		}
-		return currentConf + "\n\n" + updatedConf, nil
+		b = b.WatchesRawSource(source.Channel(sweeper.events, &handler.EnqueueRequestForObject{}))
 	}
-
-	return pattern.ReplaceAllLiteralString(currentConf, updatedConf), nil
-}
-
-func buildNginxConfig(userAgents []string) string {
-	var sb strings.Builder
-	pattern := strings.Join(userAgents, "|")
-
-	sb.WriteString(startMarker)
-	sb.WriteString("# Configuration added by kube-botblocker operator. Do not edit any of this manually\n")
-	sb.WriteString(fmt.Sprintf(`if ($http_user_agent ~* "(%s)") {`, pattern))
-	sb.WriteString("\n  return 403;\n")
-	sb.WriteString("}\n")
-	sb.WriteString(endMarker)
-
-	return sb.String()
+	return b.Named("ingress").
+		Complete(r)
 }
 
 func (r *IngressReconciler) ReconcileFanOut(ctx context.Context, obj client.Object) []ctrl.Request {
 	var (
 		requests      = []ctrl.Request{}
 		fanOutLog     = ctrl.Log.WithName("fanOutReconcile")
-		ingressConfig = obj.(*v1alpha1.IngressConfig)
+		ingressConfig = obj.(*v1beta1.IngressConfig)
 	)
 
 	var ingressList networkingv1.IngressList
@@ -292,27 +774,43 @@ func (r *IngressReconciler) ReconcileFanOut(ctx context.Context, obj client.Obje
 func ingressConfigPredicate() predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			objNew := e.ObjectNew.(*v1alpha1.IngressConfig)
+			objNew := e.ObjectNew.(*v1beta1.IngressConfig)
 			return meta.IsStatusConditionPresentAndEqual(
 				objNew.Status.Conditions,
-				v1alpha1.ConditionTypeUpdateSucceeded,
+				v1beta1.ConditionTypeUpdateSucceeded,
 				metav1.ConditionFalse,
 			)
 		},
 		CreateFunc: func(e event.CreateEvent) bool {
 			return meta.IsStatusConditionPresentAndEqual(
-				e.Object.(*v1alpha1.IngressConfig).Status.Conditions,
-				v1alpha1.ConditionTypeUpdateSucceeded,
+				e.Object.(*v1beta1.IngressConfig).Status.Conditions,
+				v1beta1.ConditionTypeUpdateSucceeded,
 				metav1.ConditionFalse,
 			)
 		},
 	}
 }
 
-func ingressPredicate() predicate.Predicate {
+// hasLeftoverConfig reports whether an Ingress without a reference to an IngressConfig still has
+// configuration added by the operator.
+func hasLeftoverConfig(ann map[string]string) bool {
+	if ann[annotations.IngressConfigNameAnnotation] != "" {
+		return false
+	}
+	_, hasSpecHash := ann[annotations.IngressConfigSpecHash]
+	_, hasGeneratedSnippet := ann[annotations.IngressGeneratedSnippet]
+	return hasSpecHash || hasGeneratedSnippet || snippet.HasMarkers(ann[annotations.IngressServerSnippet])
+}
+
+// ingressPredicate filters the Ingress events that need a reconciliation. snippetAnnotation is the
+// annotation the operator writes its configuration to.
+func ingressPredicate(snippetAnnotation string) predicate.Predicate {
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
-			return e.Object.(*networkingv1.Ingress).GetAnnotations()[annotations.IngressConfigNameAnnotation] != ""
+			// Every existing Ingress is seen as created when the operator starts, which also sweeps the
+			// configuration left on Ingresses whose reference was removed while the operator was down
+			ann := e.Object.(*networkingv1.Ingress).GetAnnotations()
+			return ann[annotations.IngressConfigNameAnnotation] != "" || hasLeftoverConfig(ann)
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			annOld := e.ObjectOld.GetAnnotations()
@@ -324,7 +822,25 @@ func ingressPredicate() predicate.Predicate {
 			specHashOld := annOld[annotations.IngressConfigSpecHash]
 			specHashNew := annNew[annotations.IngressConfigSpecHash]
 
-			return configNameOld != configNameNew || specHashOld != specHashNew
+			rendererVersionOld := annOld[annotations.IngressRendererVersion]
+			rendererVersionNew := annNew[annotations.IngressRendererVersion]
+
+			pausedOld := annOld[annotations.IngressPaused]
+			pausedNew := annNew[annotations.IngressPaused]
+
+			// Ingresses waiting for manual action are retried once the server-snippet is changed
+			// or the recorded error is removed
+			_, failedOld := annOld[annotations.IngressLastError]
+			_, failedNew := annNew[annotations.IngressLastError]
+			retry := (failedNew && annOld[snippetAnnotation] != annNew[snippetAnnotation]) ||
+				(failedOld && !failedNew)
+
+			// Changes to the server-snippet of protected Ingresses are checked for drift
+			drift := configNameNew != "" &&
+				annOld[snippetAnnotation] != annNew[snippetAnnotation]
+
+			return configNameOld != configNameNew || specHashOld != specHashNew ||
+				rendererVersionOld != rendererVersionNew || pausedOld != pausedNew || retry || drift
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			return false
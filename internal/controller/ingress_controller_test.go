@@ -17,26 +17,35 @@ limitations under the License.
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	networkingv1ac "k8s.io/client-go/applyconfigurations/networking/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
 )
 
 var _ = Describe("Ingress Controller", Ordered, func() {
 	var (
 		baseExpectedSnippet = `# kube-botblocker.github.io operator: Configuration start
 # Configuration added by kube-botblocker operator. Do not edit any of this manually
-if ($http_user_agent ~* "(GoogleBot|AI2Bot|Ai2Bot-Dolma|Amazonbot|omgili|omgilibot)") {
+if ($http_user_agent ~* "(a(?:i2bot|mazonbot)|googlebot|omgili)") {
   return 403;
 }
 # kube-botblocker.github.io operator: Configuration end`
 
 		existingSnippet = `if ($http_user_agent ~* "testingExistentConfig") {
-  return 403
+  return 404;
 }`
 	)
 
@@ -74,6 +83,14 @@ if ($http_user_agent ~* "(GoogleBot|AI2Bot|Ai2Bot-Dolma|Amazonbot|omgili|omgilib
 				verifyServerSnippet(&tc.ingress, baseExpectedSnippet)
 			})
 
+			It("Should record a SnippetApplied Event on the Ingress", func() {
+				By(fmt.Sprintf("Setting up test context with %s annotation", ingConfNameAnn))
+				tc := setupDefaultTestContext("ing-creation-event", nil)
+
+				By("Verifying the Event is recorded")
+				verifyEventRecorded(&tc.ingress, EventReasonSnippetApplied)
+			})
+
 			It("Should append to existing server-snippet", func() {
 				By(fmt.Sprintf("Setting up test context with %s annotation and existing server-snippet", ingConfNameAnn))
 				annotations := map[string]string{
@@ -193,6 +210,349 @@ if ($http_user_agent ~* "(GoogleBot|AI2Bot|Ai2Bot-Dolma|Amazonbot|omgili|omgilib
 				By("Verifying only existing snippet remains")
 				verifyServerSnippet(&tc.ingress, existingSnippet)
 			})
+
+			It("Should restore the existing server-snippet byte for byte", func() {
+				By("Setting up test context with an existing server-snippet surrounded by whitespace")
+				original := "\n" + existingSnippet + "\n\n"
+				tc := setupDefaultTestContext("ing-removal-exact", map[string]string{serverSnippetAnn: original})
+				verifyServerSnippet(&tc.ingress, original+"\n\n"+baseExpectedSnippet)
+
+				By("Removing IngressConfig annotation")
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					delete(ann, ingConfNameAnn)
+				})
+
+				By("Verifying the existing snippet is restored as it was")
+				verifyServerSnippet(&tc.ingress, original)
+				Expect(tc.ingress.GetAnnotations()).NotTo(HaveKey(originalHashAnn))
+			})
+
+			It("Should report when the existing server-snippet was changed since", func() {
+				By("Setting up test context with an existing server-snippet")
+				tc := setupDefaultTestContext("ing-removal-changed", map[string]string{serverSnippetAnn: existingSnippet})
+				verifyServerSnippet(&tc.ingress, existingSnippet+"\n\n"+baseExpectedSnippet)
+
+				By("Changing the existing snippet and removing IngressConfig annotation")
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					ann[serverSnippetAnn] = "# comment\n" + ann[serverSnippetAnn]
+					delete(ann, ingConfNameAnn)
+				})
+
+				By("Verifying only the operator configuration is removed")
+				verifyServerSnippet(&tc.ingress, "# comment\n"+existingSnippet)
+
+				By("Verifying a SnippetNotRestored Event is recorded")
+				verifyEventRecorded(&tc.ingress, EventReasonSnippetNotRestored)
+			})
+		})
+
+		Context(fmt.Sprintf("When an Ingress has leftover configuration but no %s annotation", ingConfNameAnn), func() {
+			It("Should remove the leftover configuration", func() {
+				By("Setting up Ingress as left by an annotation removed while the operator was down")
+				annotations := map[string]string{
+					serverSnippetAnn: existingSnippet + "\n\n" + baseExpectedSnippet,
+					ingSpecHashAnn:   "stale",
+				}
+				ingress := createIngress("ing-leftover", "", annotations)
+
+				By("Verifying only existing snippet remains")
+				verifyServerSnippet(&ingress, existingSnippet)
+
+				By("Verifying Ingress SpecHash is absent")
+				verifySpecHashAbsent(&ingress)
+
+				By("Verifying a SnippetRemoved Event is recorded")
+				verifyEventRecorded(&ingress, EventReasonSnippetRemoved)
+			})
+
+			It("Should not report a removed snippet when only leftover annotations are removed", func() {
+				By("Setting up Ingress with a stale SpecHash annotation and no generated configuration")
+				annotations := map[string]string{
+					serverSnippetAnn: existingSnippet,
+					ingSpecHashAnn:   "stale",
+				}
+				ingress := createIngress("ing-leftover-annotations", "", annotations)
+
+				By("Verifying Ingress SpecHash is absent")
+				verifySpecHashAbsent(&ingress)
+				Expect(ingress.GetAnnotations()).To(HaveKeyWithValue(serverSnippetAnn, existingSnippet))
+
+				By("Verifying no SnippetRemoved Event is recorded")
+				verifyEventNotRecorded(&ingress, EventReasonSnippetRemoved)
+			})
+		})
+
+		Context("When writing the Ingress annotations", func() {
+			It("Should own the managed annotations in managedFields", func() {
+				By("Setting up test context")
+				tc := setupDefaultTestContext("ing-ssa-owner", nil)
+				verifyServerSnippet(&tc.ingress, baseExpectedSnippet)
+
+				By("Verifying the operator field manager owns the managed annotations")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+					owned := annotationsOwnedBy(&tc.ingress, FieldManager)
+					g.Expect(owned).To(HaveKey(serverSnippetAnn))
+					g.Expect(owned).To(HaveKey(ingSpecHashAnn))
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("Should report a conflict when the server-snippet is applied by another field manager", func() {
+				By("Setting up Ingress with a server-snippet applied by another field manager")
+				ingressConfig := createIngressConfig("ing-ssa-conflict", nil)
+				ingress := createIngress("ing-ssa-conflict", "", nil)
+				data, err := json.Marshal(networkingv1ac.Ingress(ingress.Name, ingress.Namespace).
+					WithAnnotations(map[string]string{serverSnippetAnn: existingSnippet}))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Patch(ctx, &ingress, client.RawPatch(types.ApplyPatchType, data),
+					client.FieldOwner("gitops"))).To(Succeed())
+
+				By("Adding IngressConfig annotation")
+				updateIngressAnnotations(&ingress, func(ann map[string]string) {
+					ann[ingConfNameAnn] = ingressConfig.Name
+				})
+
+				By("Verifying the conflict is recorded and the server-snippet is kept")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingress), &ingress)).To(Succeed())
+					g.Expect(ingress.GetAnnotations()[lastErrorAnn]).To(ContainSubstring("gitops"))
+					g.Expect(ingress.GetAnnotations()[serverSnippetAnn]).To(Equal(existingSnippet))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+
+		Context(fmt.Sprintf("When the %s annotation is set to 'true'", pausedAnn), func() {
+			It("Should keep the operator configuration when the IngressConfig annotation is removed", func() {
+				By("Setting up test context")
+				tc := setupDefaultTestContext("ing-paused", nil)
+
+				By("Verifying initial configuration")
+				verifyServerSnippet(&tc.ingress, baseExpectedSnippet)
+
+				By("Pausing the Ingress and removing the IngressConfig annotation")
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					ann[pausedAnn] = "true"
+					delete(ann, ingConfNameAnn)
+				})
+
+				By("Verifying server-snippet is kept")
+				Consistently(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+					g.Expect(tc.ingress.GetAnnotations()[serverSnippetAnn]).To(Equal(baseExpectedSnippet))
+				}, 5*time.Second, interval).Should(Succeed())
+
+				By("Unpausing the Ingress")
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					delete(ann, pausedAnn)
+				})
+
+				By("Verifying server snippet is removed")
+				verifyServerSnippetAbsent(&tc.ingress)
+			})
+		})
+
+		Context("When the generated configuration is edited", func() {
+			It("Should restore the generated configuration and keep the rest of the server-snippet", func() {
+				By("Setting up test context")
+				tc := setupDefaultTestContext("ing-drift", map[string]string{serverSnippetAnn: existingSnippet})
+				expectedCombined := existingSnippet + "\n\n" + baseExpectedSnippet
+				verifyServerSnippet(&tc.ingress, expectedCombined)
+
+				By("Editing the generated configuration")
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					ann[serverSnippetAnn] = strings.Replace(ann[serverSnippetAnn], "return 403;", "return 200;", 1)
+				})
+
+				By("Verifying the generated configuration is restored")
+				verifyServerSnippet(&tc.ingress, expectedCombined)
+
+				By("Verifying a DriftCorrected Event is recorded")
+				verifyEventRecorded(&tc.ingress, EventReasonDriftCorrected)
+			})
+		})
+
+		Context("When the configuration was rendered by an earlier renderer version", func() {
+			It("Should render it again instead of reporting drift", func() {
+				By("Setting up test context")
+				tc := setupDefaultTestContext("ing-rerender", map[string]string{serverSnippetAnn: existingSnippet})
+				expectedCombined := existingSnippet + "\n\n" + baseExpectedSnippet
+				verifyServerSnippet(&tc.ingress, expectedCombined)
+				Expect(tc.ingress.GetAnnotations()).To(HaveKeyWithValue(rendererVersionAnn, fmt.Sprint(snippet.RendererVersion)))
+
+				By("Replacing the configuration with the output of an earlier renderer")
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					ann[serverSnippetAnn] = strings.Replace(ann[serverSnippetAnn], "return 403;", "return 403; # v0", 1)
+					ann[rendererVersionAnn] = "0"
+				})
+
+				By("Verifying the configuration is rendered again with the current renderer")
+				verifyServerSnippet(&tc.ingress, expectedCombined)
+				Expect(tc.ingress.GetAnnotations()).To(HaveKeyWithValue(rendererVersionAnn, fmt.Sprint(snippet.RendererVersion)))
+
+				By("Verifying no DriftCorrected Event is recorded")
+				verifyEventNotRecorded(&tc.ingress, EventReasonDriftCorrected)
+			})
+		})
+
+		Context("When the generated configuration keeps being reverted", func() {
+			It("Should back off and report the fight on the IngressConfig", func() {
+				By("Setting up test context")
+				tc := setupDefaultTestContext("ing-fight", map[string]string{serverSnippetAnn: existingSnippet})
+				expectedCombined := existingSnippet + "\n\n" + baseExpectedSnippet
+				verifyServerSnippet(&tc.ingress, expectedCombined)
+
+				By("Reverting the server-snippet until the operator backs off")
+				// Default FIGHT_DETECTION_THRESHOLD
+				for range 5 {
+					updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+						ann[serverSnippetAnn] = existingSnippet
+					})
+					verifyServerSnippet(&tc.ingress, expectedCombined)
+				}
+				updateIngressAnnotations(&tc.ingress, func(ann map[string]string) {
+					ann[serverSnippetAnn] = existingSnippet
+				})
+
+				By("Verifying the server-snippet is no longer restored")
+				Consistently(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+					g.Expect(tc.ingress.GetAnnotations()[serverSnippetAnn]).To(Equal(existingSnippet))
+				}, 5*time.Second, interval).Should(Succeed())
+
+				By("Verifying the IngressConfig reports the fight")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingressConfig), &tc.ingressConfig)).To(Succeed())
+					condition := meta.FindStatusCondition(tc.ingressConfig.Status.Conditions,
+						v1beta1.ConditionTypeFightingWithManager)
+					g.Expect(condition).NotTo(BeNil())
+					g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+					g.Expect(condition.Message).To(ContainSubstring(tc.ingress.Name))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+
+		Context("When the server-snippet is not valid NGINX configuration", func() {
+			It("Should record the error on the Ingress instead of writing the configuration", func() {
+				By("Creating an IngressConfig")
+				ingressConfig := createIngressConfig("ing-invalid-nginx", nil)
+
+				By("Creating an Ingress with a server-snippet missing a semicolon")
+				invalidSnippet := "location /health {\n  return 200\n}"
+				ingress := createIngress("ing-invalid-nginx", "", map[string]string{
+					ingConfNameAnn:   ingressConfig.Name,
+					serverSnippetAnn: invalidSnippet,
+				})
+
+				By("Verifying the error is recorded on the Ingress and the server-snippet is left untouched")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingress), &ingress)).To(Succeed())
+					g.Expect(ingress.GetAnnotations()[lastErrorAnn]).To(ContainSubstring("not valid NGINX configuration"))
+				}, timeout, interval).Should(Succeed())
+				Expect(ingress.GetAnnotations()[serverSnippetAnn]).To(Equal(invalidSnippet))
+				verifySpecHashAbsent(&ingress)
+
+				By("Verifying an InvalidSnippet Event is recorded")
+				verifyEventRecorded(&ingress, EventReasonInvalidSnippet)
+
+				By("Fixing the server-snippet")
+				updateIngressAnnotations(&ingress, func(ann map[string]string) {
+					ann[serverSnippetAnn] = existingSnippet
+				})
+
+				By("Verifying the configuration is applied and the error is removed")
+				verifyServerSnippet(&ingress, existingSnippet+"\n\n"+baseExpectedSnippet)
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingress), &ingress)).To(Succeed())
+					g.Expect(ingress.GetAnnotations()).NotTo(HaveKey(lastErrorAnn))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+
+		Context("When the server-snippet markers are broken", func() {
+			It("Should record the error on the Ingress and retry once the server-snippet is fixed", func() {
+				By("Creating an IngressConfig")
+				ingressConfig := createIngressConfig("ing-broken-markers", nil)
+
+				By("Creating an Ingress with a broken server-snippet")
+				ingress := createIngress("ing-broken-markers", "", map[string]string{
+					ingConfNameAnn:   ingressConfig.Name,
+					serverSnippetAnn: "# kube-botblocker.github.io operator: Configuration start\n",
+				})
+
+				By("Verifying the error is recorded on the Ingress")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingress), &ingress)).To(Succeed())
+					g.Expect(ingress.GetAnnotations()[lastErrorAnn]).To(ContainSubstring("markers"))
+				}, timeout, interval).Should(Succeed())
+				verifySpecHashAbsent(&ingress)
+
+				By("Fixing the server-snippet")
+				updateIngressAnnotations(&ingress, func(ann map[string]string) {
+					ann[serverSnippetAnn] = existingSnippet
+				})
+
+				By("Verifying the configuration is applied and the error is removed")
+				verifyServerSnippet(&ingress, existingSnippet+"\n\n"+baseExpectedSnippet)
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingress), &ingress)).To(Succeed())
+					g.Expect(ingress.GetAnnotations()).NotTo(HaveKey(lastErrorAnn))
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("Should rebuild the block when the IngressConfig allows it", func() {
+				By("Creating an IngressConfig with the RebuildManagedBlock repair policy")
+				ingressConfig := createIngressConfig("ing-repair-markers", nil)
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingressConfig), &ingressConfig)).To(Succeed())
+					ingressConfig.Spec.MarkerRepairPolicy = v1beta1.MarkerRepairRebuildManagedBlock
+					g.Expect(k8sClient.Update(ctx, &ingressConfig)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				By("Creating an Ingress with a broken server-snippet")
+				brokenSnippet := existingSnippet + "\n\n# kube-botblocker.github.io operator: Configuration start\n"
+				ingress := createIngress("ing-repair-markers", "", map[string]string{
+					ingConfNameAnn:   ingressConfig.Name,
+					serverSnippetAnn: brokenSnippet,
+				})
+
+				By("Verifying the block is rebuilt and the previous content is kept for audit")
+				verifyServerSnippet(&ingress, existingSnippet+"\n\n"+baseExpectedSnippet)
+				Expect(ingress.GetAnnotations()[repairedAnn]).To(Equal(brokenSnippet))
+				Expect(ingress.GetAnnotations()).NotTo(HaveKey(lastErrorAnn))
+			})
+		})
+
+		Context("When the IngressConfig has a namespaceSelector", func() {
+			It("Should only configure Ingresses from matching namespaces", func() {
+				By("Creating an IngressConfig restricted to other namespaces")
+				ingressConfig := createIngressConfig("ing-ns-selector", nil)
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingressConfig), &ingressConfig)).To(Succeed())
+					ingressConfig.Spec.NamespaceSelector = &metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": "not-" + defaultTestNamespace},
+					}
+					g.Expect(k8sClient.Update(ctx, &ingressConfig)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				By("Creating an Ingress referencing it")
+				ingress := createIngress("ing-ns-selector", "", map[string]string{ingConfNameAnn: ingressConfig.Name})
+
+				By("Verifying server-snippet is not added")
+				Consistently(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingress), &ingress)).To(Succeed())
+					g.Expect(metav1.HasAnnotation(ingress.ObjectMeta, serverSnippetAnn)).To(BeFalse())
+				}, 5*time.Second, interval).Should(Succeed())
+
+				By("Allowing the Ingress namespace")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingressConfig), &ingressConfig)).To(Succeed())
+					ingressConfig.Spec.NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"] = defaultTestNamespace
+					g.Expect(k8sClient.Update(ctx, &ingressConfig)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				By("Verifying server-snippet is added")
+				verifyServerSnippet(&ingress, baseExpectedSnippet)
+			})
 		})
 	})
 
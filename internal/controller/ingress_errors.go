@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// IngressErrors keeps the last error returned when updating each Ingress, such as an admission denial from
+// ingress-nginx or a conflict, so the IngressConfigReconciler can report it. It is shared by both reconcilers.
+// A nil *IngressErrors is valid and records nothing.
+type IngressErrors struct {
+	mu     sync.RWMutex
+	errors map[types.NamespacedName]string
+}
+
+// NewIngressErrors returns an empty IngressErrors.
+func NewIngressErrors() *IngressErrors {
+	return &IngressErrors{errors: make(map[types.NamespacedName]string)}
+}
+
+// Set records err as the last error of the Ingress.
+func (e *IngressErrors) Set(key types.NamespacedName, err error) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors[key] = err.Error()
+}
+
+// Clear forgets the last error of the Ingress.
+func (e *IngressErrors) Clear(key types.NamespacedName) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.errors, key)
+}
+
+// Get returns the last error of the Ingress, or an empty string if there is none.
+func (e *IngressErrors) Get(key types.NamespacedName) string {
+	if e == nil {
+		return ""
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.errors[key]
+}
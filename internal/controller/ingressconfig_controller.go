@@ -21,26 +21,49 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	"github.com/GustavoJST/kube-botblocker/api/v1alpha1"
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/internal/metrics"
 	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+	"github.com/GustavoJST/kube-botblocker/pkg/environment"
 	"github.com/GustavoJST/kube-botblocker/pkg/indexer"
+	"github.com/GustavoJST/kube-botblocker/pkg/nginx"
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
 )
 
+// CleanupFinalizer keeps an IngressConfig from being deleted until its configuration is handled on the
+// Ingresses referencing it, according to its deletion policy.
+const CleanupFinalizer = "kube-botblocker.github.io/cleanup"
+
+// legacyCleanupFinalizer is the finalizer used by earlier releases. It is replaced by CleanupFinalizer.
+const legacyCleanupFinalizer = "batch.tutorial.kubebuilder.io/finalizer"
+
 // IngressConfigReconciler reconciles a IngressConfig object
 type IngressConfigReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
+	Errors      *IngressErrors
+	Fights      *FightDetector
+	Environment *environment.OperatorEnv
 }
 
 // +kubebuilder:rbac:groups=kube-botblocker.github.io,resources=ingressconfigs,verbs=get;list;watch;update;patch;delete
@@ -50,43 +73,97 @@ type IngressConfigReconciler struct {
 func (r *IngressConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
-	var ingressConfig v1alpha1.IngressConfig
+	var ingressConfig v1beta1.IngressConfig
 	if err := r.Get(ctx, req.NamespacedName, &ingressConfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.ForgetIngressConfig(req.Namespace, req.Name)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	finalizer := "batch.tutorial.kubebuilder.io/finalizer"
-
 	if ingressConfig.DeletionTimestamp.IsZero() {
-		if !controllerutil.ContainsFinalizer(&ingressConfig, finalizer) {
-			controllerutil.AddFinalizer(&ingressConfig, finalizer)
+		if !controllerutil.ContainsFinalizer(&ingressConfig, CleanupFinalizer) ||
+			controllerutil.ContainsFinalizer(&ingressConfig, legacyCleanupFinalizer) {
+			// Replacing the legacy finalizer in the same update as adding the new one means the
+			// IngressConfig is never left without a finalizer
+			controllerutil.AddFinalizer(&ingressConfig, CleanupFinalizer)
+			controllerutil.RemoveFinalizer(&ingressConfig, legacyCleanupFinalizer)
 			if err := r.Update(ctx, &ingressConfig); err != nil {
 				return ctrl.Result{}, err
 			}
 			return ctrl.Result{Requeue: true}, nil
 		}
 	} else {
-		if controllerutil.ContainsFinalizer(&ingressConfig, finalizer) {
-			requeue, err := r.cleanupConfig(ctx, ingressConfig)
-			if err != nil {
-				return ctrl.Result{}, err
+		// Finalizers can't be added to objects being deleted, so the legacy finalizer is handled as the new one
+		if controllerutil.ContainsFinalizer(&ingressConfig, CleanupFinalizer) ||
+			controllerutil.ContainsFinalizer(&ingressConfig, legacyCleanupFinalizer) {
+			policy := ingressConfig.Spec.DeletionPolicy
+
+			// Deletions the webhook didn't deny, such as with webhooks disabled, wait for the references to be removed
+			if policy == v1beta1.DeletionPolicyBlock {
+				blocked, err := r.deletionBlocked(ctx, &ingressConfig)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				if blocked {
+					return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+				}
 			}
 
-			if requeue {
-				return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			if policy != v1beta1.DeletionPolicyOrphan {
+				requeue, err := r.cleanupConfig(ctx, ingressConfig)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+
+				if requeue {
+					return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+				}
 			}
 
-			controllerutil.RemoveFinalizer(&ingressConfig, finalizer)
+			controllerutil.RemoveFinalizer(&ingressConfig, CleanupFinalizer)
+			controllerutil.RemoveFinalizer(&ingressConfig, legacyCleanupFinalizer)
 			if err := r.Update(ctx, &ingressConfig); err != nil {
 				return ctrl.Result{}, err
 			}
+			if policy == v1beta1.DeletionPolicyOrphan {
+				r.Recorder.Event(&ingressConfig, corev1.EventTypeNormal, EventReasonCleanupSkipped,
+					"Configuration left in place on referencing Ingresses by deletionPolicy Orphan")
+			} else {
+				r.Recorder.Event(&ingressConfig, corev1.EventTypeNormal, EventReasonCleanupCompleted,
+					"Configuration removed from all Ingresses")
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 
-	if ingressConfig.Status.SpecHash == "" || ingressConfig.Generation != ingressConfig.Status.ObservedGeneration {
+	if ingressConfig.Spec.Suspend {
+		return r.suspend(ctx, &ingressConfig)
+	}
+
+	// A new renderer version is rolled out like a spec change, so the re-render of the Ingresses is reported
+	if ingressConfig.Status.SpecHash == "" || ingressConfig.Generation != ingressConfig.Status.ObservedGeneration ||
+		ingressConfig.Status.RendererVersion != snippet.RendererVersion {
+		rerender := ingressConfig.Status.SpecHash != "" &&
+			ingressConfig.Generation == ingressConfig.Status.ObservedGeneration
+		// The spec hash is left untouched, so Ingresses keep the configuration of the last accepted spec
+		rendered := snippet.Build(ingressConfig.Spec.Patterns(), ingressConfig.Spec.Response.GetStatusCode())
+		if budget := r.Environment.MaxSnippetSize; budget > 0 && len(rendered) > budget {
+			if setSnippetTooLargeConditions(&ingressConfig, len(rendered), budget) {
+				if err := r.Status().Update(ctx, &ingressConfig); err != nil {
+					log.Error(err, "Failed to update IngressConfig status with oversized configuration")
+					return ctrl.Result{}, err
+				}
+				condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, v1beta1.ConditionTypeDegraded)
+				log.Info("Rendered configuration exceeds the size budget; rollout refused",
+					"size", len(rendered), "budget", budget)
+				r.Recorder.Event(&ingressConfig, corev1.EventTypeWarning, EventReasonSnippetTooLarge, condition.Message)
+			}
+			return ctrl.Result{}, nil
+		}
+
 		now := metav1.NewTime(time.Now().UTC())
-		specHash, err := hashObj(ingressConfig.Spec)
+		specHash, err := renderingHash(&ingressConfig.Spec)
 		if err != nil {
 			log.Error(err, "Failed hashing IngressConfig Spec")
 			return ctrl.Result{}, err
@@ -95,21 +172,42 @@ func (r *IngressConfigReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		ingressConfig.Status.LastUpdated = &now
 		ingressConfig.Status.ObservedGeneration = ingressConfig.Generation
 		ingressConfig.Status.SpecHash = specHash
+		ingressConfig.Status.RendererVersion = snippet.RendererVersion
+		ingressConfig.Status.RedundantUserAgents = redundantUserAgents(&ingressConfig.Spec)
 		newCondition := metav1.Condition{
-			Type:               v1alpha1.ConditionTypeUpdateSucceeded,
+			Type:               v1beta1.ConditionTypeUpdateSucceeded,
 			Status:             metav1.ConditionFalse,
-			Reason:             v1alpha1.ConditionReasonReconciliationInProgress,
+			Reason:             v1beta1.ConditionReasonReconciliationInProgress,
 			Message:            "Waiting for all Ingresses to be updated",
 			LastTransitionTime: now,
 		}
-		setStatusCondition(&ingressConfig, newCondition)
+		setConditions(&ingressConfig, newCondition)
+		setRolloutConditions(&ingressConfig, ingressConfig.Status.ProtectedIngresses, 0, 0, false)
+
+		// Resuming always bumps the generation, so this is the place to lift the suspension
+		if meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, v1beta1.ConditionTypeSuspended) {
+			setConditions(&ingressConfig, metav1.Condition{
+				Type:               v1beta1.ConditionTypeSuspended,
+				Status:             metav1.ConditionFalse,
+				Reason:             v1beta1.ConditionReasonResumed,
+				Message:            "Rollout resumed",
+				LastTransitionTime: now,
+			})
+		}
 
 		// Update status with new SpecHash so the Ingress reconcile fanout can begin
 		if err := r.Status().Update(ctx, &ingressConfig); err != nil {
 			log.Error(err, "Failed to update IngressConfig status during Ingress fanout")
 			return ctrl.Result{}, err
 		}
-		log.Info("Rolling update on all associated Ingresses")
+		log.Info("Rolling update on all associated Ingresses", "rendererVersion", snippet.RendererVersion)
+		if rerender {
+			r.Recorder.Eventf(&ingressConfig, corev1.EventTypeNormal, EventReasonRolloutStarted,
+				"Rendering associated Ingresses again with renderer version %d", snippet.RendererVersion)
+		} else {
+			r.Recorder.Eventf(&ingressConfig, corev1.EventTypeNormal, EventReasonRolloutStarted,
+				"Rolling out generation %d to associated Ingresses", ingressConfig.Generation)
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -123,53 +221,237 @@ func (r *IngressConfigReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	total := int32(len(ingressList.Items))
-	var updated int32 = 0
-	for _, ing := range ingressList.Items {
-		ann := ing.GetAnnotations()
-		if ann[annotations.IngressConfigSpecHash] == ingressConfig.Status.SpecHash {
-			updated++
+	updated, failed, inventory := rolloutInventory(&ingressConfig, ingressList.Items, r.Errors,
+		r.Environment.SnippetAnnotation())
+	statusChanged := ingressConfig.Status.ProtectedIngresses != total ||
+		ingressConfig.Status.UpdatedIngresses != updated ||
+		ingressConfig.Status.FailedIngresses != failed ||
+		!equality.Semantic.DeepEqual(ingressConfig.Status.Ingresses, inventory)
+	ingressConfig.Status.ProtectedIngresses = total
+	ingressConfig.Status.UpdatedIngresses = updated
+	ingressConfig.Status.FailedIngresses = failed
+	ingressConfig.Status.Ingresses = inventory
+
+	wasDeadlineExceeded := isRolloutDeadlineExceeded(&ingressConfig)
+	deadlineExceeded := rolloutDeadlineExceeded(&ingressConfig, time.Now())
+	if setRolloutConditions(&ingressConfig, total, updated, failed, deadlineExceeded) {
+		statusChanged = true
+	}
+
+	// Fights are checked again until they are over, so the condition is cleared
+	var result ctrl.Result
+	wasFighting := meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, v1beta1.ConditionTypeFightingWithManager)
+	if r.Fights != nil {
+		fighting := r.Fights.Fighting(req.NamespacedName, time.Now())
+		if setFightingCondition(&ingressConfig, fighting) {
+			statusChanged = true
+		}
+		if len(fighting) > 0 {
+			result.RequeueAfter = stuckRolloutRequeueInterval
 		}
 	}
+	if !wasFighting && meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, v1beta1.ConditionTypeFightingWithManager) {
+		condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, v1beta1.ConditionTypeFightingWithManager)
+		log.Info("Configuration of Ingresses keeps being reverted")
+		r.Recorder.Event(&ingressConfig, corev1.EventTypeWarning, EventReasonFightingWithManager, condition.Message)
+	}
+
+	renderedSnippet := snippet.Build(ingressConfig.Spec.Patterns(), ingressConfig.Spec.Response.GetStatusCode())
+	metrics.SetIngressConfigState(ingressConfig.Namespace, ingressConfig.Name, ingressConfig.Status.SpecHash,
+		total, updated, len(renderedSnippet))
 
-	isReady := meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, v1alpha1.ConditionTypeUpdateSucceeded)
+	isReady := meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, v1beta1.ConditionTypeUpdateSucceeded)
 
 	if total == updated && !isReady {
 		now := metav1.NewTime(time.Now().UTC())
 		newCondition := metav1.Condition{
-			Type:               v1alpha1.ConditionTypeUpdateSucceeded,
+			Type:               v1beta1.ConditionTypeUpdateSucceeded,
 			Status:             metav1.ConditionTrue,
-			Reason:             v1alpha1.ConditionReasonReconciliationSuccessful,
+			Reason:             v1beta1.ConditionReasonReconciliationSuccessful,
 			Message:            "Ready for usage",
 			LastTransitionTime: now,
 		}
 		if total > 0 {
 			newCondition.Message = "All Ingresses successfully reconciled"
 		}
-		setStatusCondition(&ingressConfig, newCondition)
+		setConditions(&ingressConfig, newCondition)
 
 		log.Info("All associated Ingresses are updated. Setting status to ready.")
 		if err := r.Status().Update(ctx, &ingressConfig); err != nil {
 			log.Error(err, "Failed to update IngressConfig status to ready")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		r.Recorder.Eventf(&ingressConfig, corev1.EventTypeNormal, EventReasonRolloutCompleted,
+			"Rolled out generation %d to %d Ingresses", ingressConfig.Generation, total)
+		if ingressConfig.Status.LastUpdated != nil {
+			metrics.RolloutDuration.WithLabelValues(ingressConfig.Namespace, ingressConfig.Name).
+				Observe(now.Sub(ingressConfig.Status.LastUpdated.Time).Seconds())
+		}
+		return result, nil
+	}
+
+	if statusChanged {
+		if err := r.Status().Update(ctx, &ingressConfig); err != nil {
+			log.Error(err, "Failed to update IngressConfig rollout status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if isRolloutDeadlineExceeded(&ingressConfig) {
+		if !wasDeadlineExceeded {
+			condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, v1beta1.ConditionTypeDegraded)
+			log.Info("Rollout deadline exceeded", "updated", updated, "failed", failed, "total", total)
+			r.Recorder.Event(&ingressConfig, corev1.EventTypeWarning, EventReasonRolloutDeadlineExceeded, condition.Message)
+		}
+		return ctrl.Result{RequeueAfter: stuckRolloutRequeueInterval}, nil
 	}
 
 	if total != updated {
-		log.Info("Waiting for Ingress updates to complete", "updated", updated, "total", total)
+		log.Info("Waiting for Ingress updates to complete", "updated", updated, "failed", failed, "total", total)
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
+	return result, nil
+}
+
+// maxIngressInventory is the maximum number of entries of .status.ingresses.
+const maxIngressInventory = 50
+
+// maxRedundantUserAgents is the maximum number of entries of .status.redundantUserAgents.
+const maxRedundantUserAgents = 50
+
+// stuckRolloutRequeueInterval is how often a rollout past its deadline is checked again.
+const stuckRolloutRequeueInterval = time.Minute
+
+// redundantUserAgents lists the BlockedUserAgents entries snippet.Compile leaves out of the configuration.
+func redundantUserAgents(spec *v1beta1.IngressConfigSpec) []v1beta1.RedundantUserAgent {
+	_, redundant := snippet.Compile(spec.Patterns(), snippet.MaxRegexLength)
+	var entries []v1beta1.RedundantUserAgent
+	for _, entry := range redundant[:min(len(redundant), maxRedundantUserAgents)] {
+		entries = append(entries, v1beta1.RedundantUserAgent{Pattern: entry.Pattern, CoveredBy: entry.CoveredBy})
+	}
+	return entries
+}
+
+// rolloutInventory counts the Ingresses already configured with the current spec hash of the IngressConfig and
+// renderer version and the ones that can't be, and lists the Ingresses still behind, failed ones first. Failures are found
+// by rendering the configuration of each Ingress the same way the IngressReconciler does, or are the last
+// error the IngressReconciler got when updating the Ingress. snippetAnnotation is the annotation the
+// configuration is written to.
+func rolloutInventory(
+	ingressConfig *v1beta1.IngressConfig,
+	ingresses []networkingv1.Ingress,
+	ingressErrors *IngressErrors,
+	snippetAnnotation string,
+) (updated, failed int32, inventory []v1beta1.IngressRolloutStatus) {
+	desiredSnippet := snippet.Build(ingressConfig.Spec.Patterns(), ingressConfig.Spec.Response.GetStatusCode())
+
+	for _, ing := range ingresses {
+		ann := ing.GetAnnotations()
+		if ann[annotations.IngressConfigSpecHash] == ingressConfig.Status.SpecHash &&
+			renderedWith(ann) == snippet.RendererVersion {
+			updated++
+			continue
+		}
+
+		entry := v1beta1.IngressRolloutStatus{
+			Namespace:        ing.Namespace,
+			Name:             ing.Name,
+			ObservedSpecHash: ann[annotations.IngressConfigSpecHash],
+		}
+		if _, configured := ann[annotations.IngressConfigSpecHash]; configured {
+			entry.ObservedRendererVersion = renderedWith(ann)
+		}
+		if updatedSnippet, _, err := snippet.Apply(
+			ann[snippetAnnotation], desiredSnippet, ingressConfig.Spec.MarkerRepairPolicy,
+		); err != nil {
+			entry.LastError = err.Error()
+		} else if err := nginx.Validate(updatedSnippet); err != nil {
+			entry.LastError = fmt.Sprintf("server-snippet is not valid NGINX configuration: %s", err)
+		} else {
+			entry.LastError = ingressErrors.Get(client.ObjectKeyFromObject(&ing))
+		}
+		if entry.LastError != "" {
+			failed++
+		}
+		inventory = append(inventory, entry)
+	}
+
+	sort.SliceStable(inventory, func(i, j int) bool {
+		if (inventory[i].LastError != "") != (inventory[j].LastError != "") {
+			return inventory[i].LastError != ""
+		}
+		if inventory[i].Namespace != inventory[j].Namespace {
+			return inventory[i].Namespace < inventory[j].Namespace
+		}
+		return inventory[i].Name < inventory[j].Name
+	})
+	if len(inventory) > maxIngressInventory {
+		inventory = inventory[:maxIngressInventory]
+	}
+
+	return updated, failed, inventory
+}
+
+// suspend marks the IngressConfig as suspended. The spec hash and observed generation are left untouched,
+// so no rollout starts until the IngressConfig is resumed.
+func (r *IngressConfigReconciler) suspend(ctx context.Context, ingressConfig *v1beta1.IngressConfig) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// The rollout state is the one left by the last reconciliation before the suspension
+	renderedSnippet := snippet.Build(ingressConfig.Spec.Patterns(), ingressConfig.Spec.Response.GetStatusCode())
+	metrics.SetIngressConfigState(ingressConfig.Namespace, ingressConfig.Name, ingressConfig.Status.SpecHash,
+		ingressConfig.Status.ProtectedIngresses, ingressConfig.Status.UpdatedIngresses, len(renderedSnippet))
+
+	changed := setSuspendedConditions(ingressConfig)
+	// Spec changes made while suspended bump the generation as well, so the condition is set every time to
+	// keep its observedGeneration current
+	if setConditions(ingressConfig, metav1.Condition{
+		Type:               v1beta1.ConditionTypeSuspended,
+		Status:             metav1.ConditionTrue,
+		Reason:             v1beta1.ConditionReasonSuspended,
+		Message:            "Rollout to associated Ingresses is suspended",
+		LastTransitionTime: metav1.NewTime(time.Now().UTC()),
+	}) {
+		changed = true
+	}
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("IngressConfig is suspended. Skipping rollout.")
+	if err := r.Status().Update(ctx, ingressConfig); err != nil {
+		log.Error(err, "Failed to update IngressConfig status to suspended")
+		return ctrl.Result{}, err
+	}
 	return ctrl.Result{}, nil
 }
 
 func (r *IngressConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.IngressConfig{}).
-		Named("ingressconfig").
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.IngressConfig{})
+	if r.Fights != nil {
+		// Reports Ingresses starting to fight with another field manager right away
+		b = b.WatchesRawSource(source.Channel(r.Fights.Events(), &handler.EnqueueRequestForObject{}))
+	}
+	return b.Named("ingressconfig").
 		Complete(r)
 }
 
+// renderingHash returns the hash of what the configuration rendered for an IngressConfig depends on, so
+// changes to other fields, such as comments or policies, don't rewrite every referencing Ingress.
+func renderingHash(spec *v1beta1.IngressConfigSpec) (string, error) {
+	return hashObj(struct {
+		Patterns        []string `json:"patterns"`
+		StatusCode      int32    `json:"statusCode"`
+		RendererVersion int32    `json:"rendererVersion"`
+	}{
+		Patterns:        spec.Patterns(),
+		StatusCode:      spec.Response.GetStatusCode(),
+		RendererVersion: snippet.RendererVersion,
+	})
+}
+
 func hashObj(spec any) (string, error) {
 	jsonBytes, err := json.Marshal(spec)
 	if err != nil {
@@ -179,25 +461,46 @@ func hashObj(spec any) (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
-func setStatusCondition(ingressConfig *v1alpha1.IngressConfig, newCondition metav1.Condition) {
-	meta.SetStatusCondition(&ingressConfig.Status.Conditions, newCondition)
-	ingressConfig.Status.LastConditionStatus = newCondition.Status
-	ingressConfig.Status.LastConditionMessage = newCondition.Message
+// deletionBlocked reports whether Ingresses still reference an IngressConfig being deleted with the Block
+// deletion policy, naming them in its conditions and in an Event whenever they change.
+func (r *IngressConfigReconciler) deletionBlocked(ctx context.Context, ingressConfig *v1beta1.IngressConfig) (bool, error) {
+	var ingressList networkingv1.IngressList
+	if err := r.List(
+		ctx,
+		&ingressList,
+		&client.MatchingFields{annotations.IngressConfigNameAnnotation: ingressConfig.Name},
+	); err != nil {
+		return false, err
+	}
+	if len(ingressList.Items) == 0 {
+		return false, nil
+	}
+
+	if setDeletionBlockedConditions(ingressConfig, ingressList.Items) {
+		if err := r.Status().Update(ctx, ingressConfig); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to update IngressConfig status with blocked deletion")
+			return false, err
+		}
+		condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, v1beta1.ConditionTypeReady)
+		r.Recorder.Event(ingressConfig, corev1.EventTypeWarning, EventReasonDeletionBlocked, condition.Message)
+	}
+	return true, nil
 }
 
-func (r *IngressConfigReconciler) cleanupConfig(ctx context.Context, ingressConfig v1alpha1.IngressConfig) (bool, error) {
+func (r *IngressConfigReconciler) cleanupConfig(ctx context.Context, ingressConfig v1beta1.IngressConfig) (bool, error) {
 	log := log.FromContext(ctx).WithName("configCleanup")
 	var ingressList networkingv1.IngressList
 
-	if !meta.IsStatusConditionFalse(ingressConfig.Status.Conditions, v1alpha1.ConditionTypeCleanupSucceeded) {
+	if !meta.IsStatusConditionFalse(ingressConfig.Status.Conditions, v1beta1.ConditionTypeCleanupSucceeded) {
 		newCondition := metav1.Condition{
-			Type:               v1alpha1.ConditionTypeCleanupSucceeded,
+			Type:               v1beta1.ConditionTypeCleanupSucceeded,
 			Status:             metav1.ConditionFalse,
-			Reason:             v1alpha1.ConditionReasonCleanupInProgress,
+			Reason:             v1beta1.ConditionReasonCleanupInProgress,
 			Message:            "Cleaning configuration before removal",
 			LastTransitionTime: metav1.NewTime(time.Now().UTC()),
 		}
-		setStatusCondition(&ingressConfig, newCondition)
+		setConditions(&ingressConfig, newCondition)
+		setDeletingConditions(&ingressConfig)
 
 		if err := r.List(
 			ctx,
@@ -212,10 +515,13 @@ func (r *IngressConfigReconciler) cleanupConfig(ctx context.Context, ingressConf
 			return false, err
 		}
 
+		r.Recorder.Eventf(&ingressConfig, corev1.EventTypeNormal, EventReasonCleanupStarted,
+			"Removing configuration from %d Ingresses", len(ingressList.Items))
+
 		for _, ing := range ingressList.Items {
 			patch := client.MergeFrom(ing.DeepCopy())
 			delete(ing.GetAnnotations(), annotations.IngressConfigNameAnnotation)
-			if err := r.Patch(ctx, &ing, patch); err != nil {
+			if err := r.Patch(ctx, &ing, patch, client.FieldOwner(FieldManager)); err != nil {
 				log.Error(err, "Error cleaning up Ingress annotation", "ingress", ing)
 				return false, err
 			}
@@ -232,6 +538,15 @@ func (r *IngressConfigReconciler) cleanupConfig(ctx context.Context, ingressConf
 	}
 
 	if len(ingressList.Items) != 0 {
+		// Cleanup is requeued until the Ingresses are cleaned, so the Event is only recorded when they change
+		if setCleanupWaitingConditions(&ingressConfig, ingressList.Items) {
+			if err := r.Status().Update(ctx, &ingressConfig); err != nil {
+				log.Error(err, "Failed to update IngressConfig status during cleanup")
+				return false, err
+			}
+			condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, v1beta1.ConditionTypeProgressing)
+			r.Recorder.Event(&ingressConfig, corev1.EventTypeNormal, EventReasonCleanupWaiting, condition.Message)
+		}
 		return true, nil
 	}
 
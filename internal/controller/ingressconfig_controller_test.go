@@ -17,20 +17,22 @@ limitations under the License.
 package controller
 
 import (
+	"fmt"
 	"time"
 
-	"github.com/GustavoJST/kube-botblocker/api/v1alpha1"
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
-	expectedFinalizer = "batch.tutorial.kubebuilder.io/finalizer"
+	expectedFinalizer = "kube-botblocker.github.io/cleanup"
 )
 
 var _ = Describe("IngressConfig Controller", Ordered, func() {
@@ -48,7 +50,7 @@ var _ = Describe("IngressConfig Controller", Ordered, func() {
 		}, timeout, interval).Should(Succeed())
 	})
 
-	fetchUpdate := func(ingressConfig *v1alpha1.IngressConfig) {
+	fetchUpdate := func(ingressConfig *v1beta1.IngressConfig) {
 		Eventually(func(g Gomega) {
 			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingressConfig), ingressConfig)).To(Succeed())
 		}, timeout, interval).Should(Succeed())
@@ -62,8 +64,10 @@ var _ = Describe("IngressConfig Controller", Ordered, func() {
 			By("Waiting for initial reconciliation to complete")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastConditionMessage).To(Equal("Ready for usage"))
-				g.Expect(meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, "UpdateSucceeded")).To(BeTrue())
+				condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, "UpdateSucceeded")
+				g.Expect(condition).To(Not(BeNil()))
+				g.Expect(condition.Message).To(Equal("Ready for usage"))
+				g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
 			}, timeout, interval).Should(Succeed())
 
 			By("Having the finalizer")
@@ -93,22 +97,69 @@ var _ = Describe("IngressConfig Controller", Ordered, func() {
 
 			condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, "UpdateSucceeded")
 
-			By("Having .status.lastConditionMessage be equal to the correct condition message")
+			By("Having .status.lastUpdated be equal to the correct condition lastTransitionTime")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastConditionMessage).To(Equal(condition.Message))
+				g.Expect(ingressConfig.Status.LastUpdated.Time).To(Equal(condition.LastTransitionTime.Time))
 			}, timeout, interval).Should(Succeed())
+		})
+
+		It("Should set the Ready, Progressing and Degraded conditions", func() {
+			By("Creating the IngressConfig")
+			ingressConfig := createIngressConfig("ingressconfig-standard-conditions", nil)
 
-			By("Having .status.lastConditionStatus be equal to the correct condition status")
+			By("Waiting for the standard conditions to reflect the completed rollout")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastConditionStatus).To(Equal(condition.Status))
+				expected := map[string]metav1.ConditionStatus{
+					v1beta1.ConditionTypeReady:       metav1.ConditionTrue,
+					v1beta1.ConditionTypeProgressing: metav1.ConditionFalse,
+					v1beta1.ConditionTypeDegraded:    metav1.ConditionFalse,
+				}
+				for conditionType, status := range expected {
+					condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, conditionType)
+					g.Expect(condition).To(Not(BeNil()))
+					g.Expect(condition.Status).To(Equal(status))
+					g.Expect(condition.ObservedGeneration).To(Equal(ingressConfig.Generation))
+				}
 			}, timeout, interval).Should(Succeed())
 
-			By("Having .status.lastUpdated be equal to the correct condition lastTransitionTime")
+			By("Keeping the deprecated UpdateSucceeded condition")
+			Expect(meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, "UpdateSucceeded")).To(BeTrue())
+			Expect(meta.FindStatusCondition(ingressConfig.Status.Conditions, "UpdateSucceeded").ObservedGeneration).
+				To(Equal(ingressConfig.Generation))
+		})
+	})
+
+	Context("When a IngressConfig has the legacy finalizer", func() {
+		It("Should replace it with the cleanup finalizer", func() {
+			By("Creating the IngressConfig with the legacy finalizer")
+			ingressConfig := v1beta1.IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       makeTestName("ingressconfig-legacy-finalizer", GinkgoParallelProcess()),
+					Namespace:  defaultOperatorNamespace,
+					Finalizers: []string{legacyCleanupFinalizer},
+				},
+				Spec: v1beta1.IngressConfigSpec{
+					BlockedUserAgents: toUserAgentRules(defaultBlockedAgents),
+				},
+			}
+			Expect(k8sClient.Create(ctx, &ingressConfig)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &ingressConfig))).To(Succeed())
+			})
+
+			By("Having only the cleanup finalizer")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastUpdated.Time).To(Equal(condition.LastTransitionTime.Time))
+				g.Expect(ingressConfig.GetFinalizers()).To(Equal([]string{expectedFinalizer}))
+			}, timeout, interval).Should(Succeed())
+
+			By("Deleting the IngressConfig")
+			Expect(k8sClient.Delete(ctx, &ingressConfig)).To(Succeed())
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&ingressConfig), &ingressConfig)
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
 			}, timeout, interval).Should(Succeed())
 		})
 	})
@@ -121,8 +172,10 @@ var _ = Describe("IngressConfig Controller", Ordered, func() {
 			By("Waiting for initial reconciliation to complete")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastConditionMessage).To(Equal("Ready for usage"))
-				g.Expect(meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, "UpdateSucceeded")).To(BeTrue())
+				condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, "UpdateSucceeded")
+				g.Expect(condition).To(Not(BeNil()))
+				g.Expect(condition.Message).To(Equal("Ready for usage"))
+				g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
 			}, timeout, interval).Should(Succeed())
 
 			lastUpdatedBefore := *ingressConfig.Status.LastUpdated.DeepCopy()
@@ -152,27 +205,37 @@ var _ = Describe("IngressConfig Controller", Ordered, func() {
 			By("Having the blocked user agent list be updated")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Spec.BlockedUserAgents).To(Equal(
+				g.Expect(ingressConfig.Spec.Patterns()).To(Equal(
 					[]string{"GoogleBot", "AI2Bot", "Ai2Bot-Dolma", "Amazonbot", "omgili"},
 				))
 			}, timeout, interval).Should(Succeed())
 
+			By("Having the .status.redundantUserAgents list the entries left out of the configuration")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&ingressConfig)
+				g.Expect(ingressConfig.Status.RedundantUserAgents).To(Equal([]v1beta1.RedundantUserAgent{
+					{Pattern: "Ai2Bot-Dolma", CoveredBy: "AI2Bot"},
+				}))
+			}, timeout, interval).Should(Succeed())
+
 			By("Having the .status.observedGeneration field match .metadata.generation")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
 				g.Expect(ingressConfig.Status.ObservedGeneration).To(Equal(ingressConfig.ObjectMeta.Generation))
 			}, timeout, interval).Should(Succeed())
 
-			By("Having the .status.lastConditionMessage remain the same")
+			By("Having the UpdateSucceeded condition message remain the same")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastConditionMessage).To(Equal("Ready for usage"))
+				condition := meta.FindStatusCondition(ingressConfig.Status.Conditions, "UpdateSucceeded")
+				g.Expect(condition).To(Not(BeNil()))
+				g.Expect(condition.Message).To(Equal("Ready for usage"))
 			}, timeout, interval).Should(Succeed())
 
-			By("Having the .status.lastConditionStatus be True")
+			By("Having the UpdateSucceeded condition status be True")
 			Eventually(func(g Gomega) {
 				fetchUpdate(&ingressConfig)
-				g.Expect(ingressConfig.Status.LastConditionStatus).To(Equal(metav1.ConditionTrue))
+				g.Expect(meta.IsStatusConditionTrue(ingressConfig.Status.Conditions, "UpdateSucceeded")).To(BeTrue())
 			}, timeout, interval).Should(Succeed())
 
 			By("Having the .status.lastUpdated be updated")
@@ -217,4 +280,332 @@ var _ = Describe("IngressConfig Controller", Ordered, func() {
 			}, timeout, interval).Should(Succeed())
 		})
 	})
+
+	Context("When reporting the rollout of a IngressConfig", func() {
+		It("Should count updated and failed Ingresses and list the failed ones", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-inventory", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+
+			By("Creating an Ingress with a broken server-snippet")
+			brokenSnippet := "# kube-botblocker.github.io operator: Configuration start\n"
+			brokenIngress := createIngress("ingressconfig-inventory-broken", "", map[string]string{
+				ingConfNameAnn:   tc.ingressConfig.Name,
+				serverSnippetAnn: brokenSnippet,
+			})
+
+			By("Checking the rollout status")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(tc.ingressConfig.Status.ProtectedIngresses).To(Equal(int32(2)))
+				g.Expect(tc.ingressConfig.Status.UpdatedIngresses).To(Equal(int32(1)))
+				g.Expect(tc.ingressConfig.Status.FailedIngresses).To(Equal(int32(1)))
+				g.Expect(tc.ingressConfig.Status.Ingresses).To(HaveLen(1))
+				g.Expect(tc.ingressConfig.Status.Ingresses[0].Name).To(Equal(brokenIngress.Name))
+				g.Expect(tc.ingressConfig.Status.Ingresses[0].ObservedSpecHash).To(BeEmpty())
+				g.Expect(tc.ingressConfig.Status.Ingresses[0].LastError).To(ContainSubstring("markers"))
+			}, timeout, interval).Should(Succeed())
+
+			By("Fixing the broken server-snippet")
+			updateIngressAnnotations(&brokenIngress, func(ann map[string]string) {
+				delete(ann, serverSnippetAnn)
+			})
+
+			By("Checking the rollout status")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(tc.ingressConfig.Status.ProtectedIngresses).To(Equal(int32(2)))
+				g.Expect(tc.ingressConfig.Status.UpdatedIngresses).To(Equal(int32(2)))
+				g.Expect(tc.ingressConfig.Status.FailedIngresses).To(BeZero())
+				g.Expect(tc.ingressConfig.Status.Ingresses).To(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("Should report the Ingresses not updated once the rollout deadline is exceeded", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-deadline", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+
+			By("Setting a rollout deadline")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				deadline := int32(1)
+				tc.ingressConfig.Spec.RolloutDeadlineSeconds = &deadline
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("Creating an Ingress with a broken server-snippet")
+			brokenIngress := createIngress("ingressconfig-deadline-broken", "", map[string]string{
+				ingConfNameAnn:   tc.ingressConfig.Name,
+				serverSnippetAnn: "# kube-botblocker.github.io operator: Configuration start\n",
+			})
+
+			By("Checking the Degraded condition")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				condition := meta.FindStatusCondition(tc.ingressConfig.Status.Conditions, v1beta1.ConditionTypeDegraded)
+				g.Expect(condition).To(Not(BeNil()))
+				g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				g.Expect(condition.Reason).To(Equal(v1beta1.ConditionReasonRolloutDeadlineExceeded))
+				g.Expect(condition.Message).To(ContainSubstring(brokenIngress.Namespace + "/" + brokenIngress.Name))
+				g.Expect(condition.Message).To(ContainSubstring("markers"))
+				g.Expect(meta.IsStatusConditionFalse(tc.ingressConfig.Status.Conditions,
+					v1beta1.ConditionTypeProgressing)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	Context("When the configuration rendered for a IngressConfig exceeds the size budget", func() {
+		It("Should refuse the rollout and keep the last accepted configuration", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-too-large", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+			specHashBefore := tc.ingressConfig.Status.SpecHash
+
+			By("Updating the IngressConfig with a blocklist over the default MAX_SNIPPET_SIZE")
+			var patterns []string
+			for i := range 3000 {
+				patterns = append(patterns, fmt.Sprintf("Crawler%05d", i))
+			}
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				tc.ingressConfig.Spec.BlockedUserAgents = toUserAgentRules(patterns)
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the IngressConfig is reported as Degraded")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				condition := meta.FindStatusCondition(tc.ingressConfig.Status.Conditions, v1beta1.ConditionTypeDegraded)
+				g.Expect(condition).NotTo(BeNil())
+				g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				g.Expect(condition.Reason).To(Equal(v1beta1.ConditionReasonSnippetTooLarge))
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the rollout didn't start")
+			Consistently(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(tc.ingressConfig.Status.SpecHash).To(Equal(specHashBefore))
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.GetAnnotations()[ingSpecHashAnn]).To(Equal(specHashBefore))
+			}, 3*time.Second, interval).Should(Succeed())
+		})
+
+		It("Should not render the refused configuration for an Ingress that starts referencing it", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-too-large-new-ref", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+
+			By("Updating the IngressConfig with a blocklist over the default MAX_SNIPPET_SIZE")
+			var patterns []string
+			for i := range 3000 {
+				patterns = append(patterns, fmt.Sprintf("Crawler%05d", i))
+			}
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				tc.ingressConfig.Spec.BlockedUserAgents = toUserAgentRules(patterns)
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(meta.IsStatusConditionTrue(tc.ingressConfig.Status.Conditions,
+					v1beta1.ConditionTypeDegraded)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			By("Creating a new Ingress referencing the IngressConfig")
+			newIngress := createIngress("ingressconfig-too-large-new-ingress", "", map[string]string{
+				ingConfNameAnn: tc.ingressConfig.Name,
+			})
+
+			By("Verifying the oversized configuration is not written")
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&newIngress), &newIngress)).To(Succeed())
+				g.Expect(newIngress.GetAnnotations()).NotTo(HaveKey(serverSnippetAnn))
+				g.Expect(newIngress.GetAnnotations()).NotTo(HaveKey(ingSpecHashAnn))
+			}, 3*time.Second, interval).Should(Succeed())
+		})
+	})
+
+	Context("When changing IngressConfig fields the rendered configuration doesn't depend on", func() {
+		It("Should not rewrite the associated Ingresses", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-comment", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+			specHashBefore := tc.ingressConfig.Status.SpecHash
+			resourceVersionBefore := tc.ingress.ResourceVersion
+
+			By("Commenting a rule and changing the marker repair policy")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				tc.ingressConfig.Spec.BlockedUserAgents[0].Comment = "Search engine crawler"
+				tc.ingressConfig.Spec.MarkerRepairPolicy = v1beta1.MarkerRepairRebuildManagedBlock
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the new generation is observed with the same spec hash")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(tc.ingressConfig.Status.ObservedGeneration).To(Equal(tc.ingressConfig.Generation))
+				g.Expect(tc.ingressConfig.Status.SpecHash).To(Equal(specHashBefore))
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the Ingress is left untouched")
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.ResourceVersion).To(Equal(resourceVersionBefore))
+			}, 3*time.Second, interval).Should(Succeed())
+		})
+	})
+
+	Context("When suspending a IngressConfig with associated Ingresses", func() {
+		It("Should not roll out spec changes until resumed", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-suspend", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+			specHashBefore := tc.ingressConfig.Status.SpecHash
+
+			By("Suspending the IngressConfig")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				tc.ingressConfig.Spec.Suspend = true
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(meta.IsStatusConditionTrue(tc.ingressConfig.Status.Conditions, "Suspended")).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			By("Updating the IngressConfig Spec while suspended")
+			blockedAgents := tc.ingressConfig.Spec.BlockedUserAgents
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				tc.ingressConfig.Spec.BlockedUserAgents = blockedAgents[:len(blockedAgents)-1]
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the spec hash was not rolled out")
+			Consistently(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(tc.ingressConfig.Status.SpecHash).To(Equal(specHashBefore))
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.GetAnnotations()[ingSpecHashAnn]).To(Equal(specHashBefore))
+			}, 5*time.Second, interval).Should(Succeed())
+
+			By("Resuming the IngressConfig")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				tc.ingressConfig.Spec.Suspend = false
+				g.Expect(k8sClient.Update(ctx, &tc.ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the pending change is rolled out")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				g.Expect(meta.IsStatusConditionFalse(tc.ingressConfig.Status.Conditions, "Suspended")).To(BeTrue())
+				g.Expect(tc.ingressConfig.Status.SpecHash).To(Not(Equal(specHashBefore)))
+			}, timeout, interval).Should(Succeed())
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+		})
+	})
+
+	Context("When deleting a IngressConfig still referenced by Ingresses", func() {
+		setDeletionPolicy := func(ingressConfig *v1beta1.IngressConfig, policy v1beta1.DeletionPolicy) {
+			Eventually(func(g Gomega) {
+				fetchUpdate(ingressConfig)
+				ingressConfig.Spec.DeletionPolicy = policy
+				g.Expect(k8sClient.Update(ctx, ingressConfig)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+		}
+
+		It("Should wait for the references to be removed with the Block deletion policy", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-block", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+			setDeletionPolicy(&tc.ingressConfig, v1beta1.DeletionPolicyBlock)
+
+			By("Deleting the IngressConfig")
+			Expect(k8sClient.Delete(ctx, &tc.ingressConfig)).To(Succeed())
+
+			By("Verifying the deletion is blocked by the referencing Ingress")
+			Eventually(func(g Gomega) {
+				fetchUpdate(&tc.ingressConfig)
+				condition := meta.FindStatusCondition(tc.ingressConfig.Status.Conditions, v1beta1.ConditionTypeReady)
+				g.Expect(condition).NotTo(BeNil())
+				g.Expect(condition.Reason).To(Equal(v1beta1.ConditionReasonDeletionBlocked))
+				g.Expect(condition.Message).To(ContainSubstring(tc.ingress.Namespace + "/" + tc.ingress.Name))
+			}, timeout, interval).Should(Succeed())
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.GetAnnotations()).To(HaveKeyWithValue(ingConfNameAnn, tc.ingressConfig.Name))
+				g.Expect(tc.ingress.GetAnnotations()).To(HaveKey(ingSpecHashAnn))
+			}, 3*time.Second, interval).Should(Succeed())
+
+			By("Removing the reference from the Ingress")
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				delete(tc.ingress.Annotations, ingConfNameAnn)
+				g.Expect(k8sClient.Update(ctx, &tc.ingress)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the IngressConfig is deleted")
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingressConfig), &tc.ingressConfig)
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("Should keep the configuration of orphaned Ingresses until adopted with the Orphan deletion policy", func() {
+			By("Setting up test context")
+			tc := setupDefaultTestContext("ingressconfig-orphan", nil)
+			verifySpecHashMatch(&tc.ingress, &tc.ingressConfig)
+			setDeletionPolicy(&tc.ingressConfig, v1beta1.DeletionPolicyOrphan)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+			snippetBefore := tc.ingress.GetAnnotations()[serverSnippetAnn]
+
+			By("Deleting the IngressConfig")
+			Expect(k8sClient.Delete(ctx, &tc.ingressConfig)).To(Succeed())
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingressConfig), &tc.ingressConfig)
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the Ingress is marked as orphaned")
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.GetAnnotations()).To(HaveKeyWithValue(orphanedAnn, "true"))
+			}, timeout, interval).Should(Succeed())
+
+			By("Verifying the Ingress keeps its reference and configuration")
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.GetAnnotations()).To(HaveKeyWithValue(ingConfNameAnn, tc.ingressConfig.Name))
+				g.Expect(tc.ingress.GetAnnotations()).To(HaveKeyWithValue(serverSnippetAnn, snippetBefore))
+			}, 3*time.Second, interval).Should(Succeed())
+
+			By("Recreating the IngressConfig with the same name")
+			recreated := v1beta1.IngressConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tc.ingressConfig.Name,
+					Namespace: tc.ingressConfig.Namespace,
+				},
+				Spec: v1beta1.IngressConfigSpec{
+					BlockedUserAgents: toUserAgentRules([]string{"GPTBot"}),
+				},
+			}
+			Expect(k8sClient.Create(ctx, &recreated)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &recreated))).To(Succeed())
+			})
+
+			By("Verifying the Ingress is adopted")
+			verifySpecHashMatch(&tc.ingress, &recreated)
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&tc.ingress), &tc.ingress)).To(Succeed())
+				g.Expect(tc.ingress.GetAnnotations()).NotTo(HaveKey(orphanedAnn))
+				g.Expect(tc.ingress.GetAnnotations()[serverSnippetAnn]).To(ContainSubstring("GPTBot"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
 })
@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// leftoverSweeper periodically sends the Ingresses holding configuration added by the operator without
+// referencing an IngressConfig to the IngressReconciler, which removes it. The startup sweep only covers
+// references removed while the operator was down; this one also covers configuration added back later, for
+// example by a GitOps tool syncing an old revision of the Ingress.
+type leftoverSweeper struct {
+	reader   client.Reader
+	interval time.Duration
+	events   chan event.GenericEvent
+}
+
+func newLeftoverSweeper(reader client.Reader, interval time.Duration) *leftoverSweeper {
+	return &leftoverSweeper{
+		reader:   reader,
+		interval: interval,
+		events:   make(chan event.GenericEvent, 100),
+	}
+}
+
+// Start implements manager.Runnable. It only runs on the leader, as the controllers do.
+func (s *leftoverSweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *leftoverSweeper) sweep(ctx context.Context) {
+	var ingressList networkingv1.IngressList
+	if err := s.reader.List(ctx, &ingressList); err != nil {
+		ctrl.Log.WithName("leftoverSweeper").Error(err, "Failed to fetch list of Ingresses")
+		return
+	}
+
+	for i := range ingressList.Items {
+		if !hasLeftoverConfig(ingressList.Items[i].GetAnnotations()) {
+			continue
+		}
+		select {
+		case s.events <- event.GenericEvent{Object: &ingressList.Items[i]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
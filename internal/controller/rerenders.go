@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+)
+
+// RerenderLimiter spreads over time the Ingresses rendered again after snippet.RendererVersion changed, so an
+// upgrade of the operator doesn't rewrite every Ingress, and reload ingress-nginx for each of them, at once.
+// A nil *RerenderLimiter doesn't limit anything.
+type RerenderLimiter struct {
+	limiter *rate.Limiter
+
+	mu        sync.Mutex
+	scheduled map[types.NamespacedName]time.Time
+}
+
+// NewRerenderLimiter returns a RerenderLimiter allowing perSecond Ingresses per second, or nil if perSecond is 0.
+func NewRerenderLimiter(perSecond float64) *RerenderLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RerenderLimiter{
+		limiter:   rate.NewLimiter(rate.Limit(perSecond), 1),
+		scheduled: make(map[types.NamespacedName]time.Time),
+	}
+}
+
+// Delay returns how long to wait before rendering the Ingress again. The first call reserves a slot for the
+// Ingress, so it isn't delayed any further once reconciled again after the delay.
+func (l *RerenderLimiter) Delay(key types.NamespacedName, now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if at, ok := l.scheduled[key]; ok {
+		if now.Before(at) {
+			return at.Sub(now)
+		}
+		delete(l.scheduled, key)
+		return 0
+	}
+
+	delay := l.limiter.ReserveN(now, 1).DelayFrom(now)
+	if delay > 0 {
+		l.scheduled[key] = now.Add(delay)
+	}
+	return delay
+}
+
+// Forget drops the slot reserved for the Ingress.
+func (l *RerenderLimiter) Forget(key types.NamespacedName) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.scheduled, key)
+}
+
+// renderedWith returns the renderer version the configuration of an Ingress was rendered with.
+func renderedWith(ann map[string]string) int32 {
+	value, ok := ann[annotations.IngressRendererVersion]
+	if !ok {
+		return 1
+	}
+	version, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(version)
+}
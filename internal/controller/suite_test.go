@@ -27,6 +27,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -38,7 +39,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	"github.com/GustavoJST/kube-botblocker/api/v1alpha1"
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
 	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
 	"github.com/GustavoJST/kube-botblocker/pkg/environment"
 	// +kubebuilder:scaffold:imports
@@ -60,9 +61,15 @@ var (
 	defaultTestNamespace     = "default"
 	defaultOperatorNamespace = "kube-botblocker"
 
-	ingConfNameAnn   = annotations.IngressConfigNameAnnotation
-	ingSpecHashAnn   = annotations.IngressConfigSpecHash
-	serverSnippetAnn = annotations.IngressServerSnippet
+	ingConfNameAnn     = annotations.IngressConfigNameAnnotation
+	ingSpecHashAnn     = annotations.IngressConfigSpecHash
+	serverSnippetAnn   = annotations.IngressServerSnippet
+	pausedAnn          = annotations.IngressPaused
+	lastErrorAnn       = annotations.IngressLastError
+	repairedAnn        = annotations.IngressSnippetBeforeRepair
+	originalHashAnn    = annotations.IngressOriginalSnippetHash
+	orphanedAnn        = annotations.IngressOrphaned
+	rendererVersionAnn = annotations.IngressRendererVersion
 
 	defaultBlockedAgents = []string{
 		"GoogleBot", "AI2Bot", "Ai2Bot-Dolma",
@@ -87,7 +94,7 @@ var _ = BeforeSuite(func() {
 	ctx, cancel = context.WithCancel(context.TODO())
 
 	var err error
-	err = v1alpha1.AddToScheme(scheme.Scheme)
+	err = v1beta1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
@@ -120,16 +127,26 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	ingressErrors := NewIngressErrors()
+	fights := NewFightDetector(env.FightDetectionThreshold, env.FightDetectionWindow)
 	err = (&IngressReconciler{
 		Client:      k8sManager.GetClient(),
 		Scheme:      k8sManager.GetScheme(),
+		Recorder:    k8sManager.GetEventRecorderFor("ingress-controller"),
+		Errors:      ingressErrors,
+		Fights:      fights,
+		Rerenders:   NewRerenderLimiter(env.RerenderRate),
 		Environment: env,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
 	err = (&IngressConfigReconciler{
-		Client: k8sManager.GetClient(),
-		Scheme: k8sManager.GetScheme(),
+		Client:      k8sManager.GetClient(),
+		Scheme:      k8sManager.GetScheme(),
+		Recorder:    k8sManager.GetEventRecorderFor("ingressconfig-controller"),
+		Errors:      ingressErrors,
+		Fights:      fights,
+		Environment: env,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -171,7 +188,7 @@ func getFirstFoundEnvTestBinaryDir() string {
 }
 
 type testContext struct {
-	ingressConfig v1alpha1.IngressConfig
+	ingressConfig v1beta1.IngressConfig
 	ingress       networkingv1.Ingress
 }
 
@@ -180,7 +197,7 @@ func makeTestName(base string, suffix int) string {
 }
 
 // If blockedAgents is nil, use default user agents list for IngressConfig creation
-func createIngressConfig(baseName string, blockedAgents []string) v1alpha1.IngressConfig {
+func createIngressConfig(baseName string, blockedAgents []string) v1beta1.IngressConfig {
 	if blockedAgents == nil {
 		blockedAgents = defaultBlockedAgents
 	}
@@ -191,23 +208,24 @@ func createIngressConfig(baseName string, blockedAgents []string) v1alpha1.Ingre
 		Namespace: defaultOperatorNamespace,
 	}
 
-	ingressConfig := v1alpha1.IngressConfig{
+	ingressConfig := v1beta1.IngressConfig{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "IngressConfig",
-			APIVersion: "kube-botblocker.github.io/v1alpha1",
+			APIVersion: "kube-botblocker.github.io/v1beta1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      key.Name,
 			Namespace: key.Namespace,
 		},
-		Spec: v1alpha1.IngressConfigSpec{
-			BlockedUserAgents: blockedAgents,
+		Spec: v1beta1.IngressConfigSpec{
+			BlockedUserAgents: toUserAgentRules(blockedAgents),
 		},
 	}
 
 	Expect(k8sClient.Create(ctx, &ingressConfig)).To(Succeed())
 	DeferCleanup(func() {
-		Expect(k8sClient.Delete(ctx, &ingressConfig)).To(Succeed())
+		// Tests deleting the IngressConfig themselves leave nothing to clean up
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &ingressConfig))).To(Succeed())
 	})
 
 	Eventually(func(g Gomega) {
@@ -217,6 +235,14 @@ func createIngressConfig(baseName string, blockedAgents []string) v1alpha1.Ingre
 	return ingressConfig
 }
 
+func toUserAgentRules(patterns []string) []v1beta1.UserAgentRule {
+	rules := make([]v1beta1.UserAgentRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, v1beta1.UserAgentRule{Pattern: pattern})
+	}
+	return rules
+}
+
 func createIngress(baseName string, namespace string, annotations map[string]string) networkingv1.Ingress {
 	if namespace == "" {
 		namespace = defaultTestNamespace
@@ -266,6 +292,28 @@ func verifyServerSnippet(ingress *networkingv1.Ingress, expected string) {
 	}, timeout, interval).Should(Succeed())
 }
 
+func verifyEventRecorded(obj client.Object, reason string) {
+	Eventually(func(g Gomega) {
+		g.Expect(recordedEventReasons(g, obj)).To(ContainElement(reason))
+	}, timeout, interval).Should(Succeed())
+}
+
+func verifyEventNotRecorded(obj client.Object, reason string) {
+	Expect(recordedEventReasons(Default, obj)).NotTo(ContainElement(reason))
+}
+
+func recordedEventReasons(g Gomega, obj client.Object) []string {
+	events := &corev1.EventList{}
+	g.Expect(k8sClient.List(ctx, events, client.InNamespace(obj.GetNamespace()))).To(Succeed())
+	var reasons []string
+	for _, e := range events.Items {
+		if e.InvolvedObject.Name == obj.GetName() {
+			reasons = append(reasons, e.Reason)
+		}
+	}
+	return reasons
+}
+
 func verifyServerSnippetAbsent(ingress *networkingv1.Ingress) {
 	Eventually(func(g Gomega) {
 		g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingress), ingress)).To(Succeed())
@@ -275,7 +323,7 @@ func verifyServerSnippetAbsent(ingress *networkingv1.Ingress) {
 	}, timeout, interval).Should(Succeed())
 }
 
-func verifySpecHashMatch(ingress *networkingv1.Ingress, ingressConfig *v1alpha1.IngressConfig) {
+func verifySpecHashMatch(ingress *networkingv1.Ingress, ingressConfig *v1beta1.IngressConfig) {
 	Eventually(func(g Gomega) {
 		g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingressConfig), ingressConfig)).To(Succeed())
 		g.Expect(ingressConfig.Status.SpecHash).To(Not(BeEmpty()))
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the operator specific Prometheus metrics, registered on the
+// controller-runtime metrics registry so they are served along with the default ones.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "kube_botblocker"
+
+	labelNamespace     = "namespace"
+	labelIngressConfig = "ingressconfig"
+	labelSpecHash      = "spec_hash"
+)
+
+var (
+	// ProtectedIngresses is the number of Ingresses referencing each IngressConfig.
+	ProtectedIngresses = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingressconfig_protected_ingresses",
+		Help:      "Number of Ingresses referencing the IngressConfig.",
+	}, []string{labelNamespace, labelIngressConfig})
+
+	// OutdatedIngresses is the number of Ingresses not yet configured with the current spec of each IngressConfig.
+	OutdatedIngresses = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingressconfig_outdated_ingresses",
+		Help:      "Number of Ingresses referencing the IngressConfig that are not configured with its current spec.",
+	}, []string{labelNamespace, labelIngressConfig})
+
+	// RolloutDuration is the time taken from a spec change of an IngressConfig until all its Ingresses are updated.
+	RolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingressconfig_rollout_duration_seconds",
+		Help:      "Time from a spec change of the IngressConfig until all referencing Ingresses are updated.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 14),
+	}, []string{labelNamespace, labelIngressConfig})
+
+	// SnippetSize is the size in bytes of the configuration rendered for each IngressConfig.
+	SnippetSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingressconfig_snippet_size_bytes",
+		Help:      "Size in bytes of the server-snippet block rendered for the IngressConfig.",
+	}, []string{labelNamespace, labelIngressConfig})
+
+	// MarkerMismatchErrors counts the Ingresses that couldn't be updated because of malformed configuration markers.
+	// The ingressconfig label is empty when the configuration was being removed from the Ingress.
+	MarkerMismatchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "marker_mismatch_errors_total",
+		Help:      "Number of Ingress updates that failed because of malformed configuration markers in the server-snippet.",
+	}, []string{labelIngressConfig})
+
+	// DriftCorrections counts the generated blocks restored after being changed outside of the operator.
+	DriftCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "drift_corrections_total",
+		Help:      "Number of generated server-snippet blocks restored after being changed outside of the operator.",
+	}, []string{labelIngressConfig})
+
+	// IngressConfigInfo exposes the current spec hash of each IngressConfig.
+	IngressConfigInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ingressconfig_info",
+		Help:      "Information about the IngressConfig. Always 1.",
+	}, []string{labelNamespace, labelIngressConfig, labelSpecHash})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		ProtectedIngresses,
+		OutdatedIngresses,
+		RolloutDuration,
+		SnippetSize,
+		MarkerMismatchErrors,
+		DriftCorrections,
+		IngressConfigInfo,
+	)
+}
+
+// SetIngressConfigState records the rollout state and spec hash of an IngressConfig.
+func SetIngressConfigState(namespace, name, specHash string, protected, updated int32, snippetSize int) {
+	ProtectedIngresses.WithLabelValues(namespace, name).Set(float64(protected))
+	OutdatedIngresses.WithLabelValues(namespace, name).Set(float64(protected - updated))
+	SnippetSize.WithLabelValues(namespace, name).Set(float64(snippetSize))
+
+	IngressConfigInfo.DeletePartialMatch(prometheus.Labels{labelNamespace: namespace, labelIngressConfig: name})
+	IngressConfigInfo.WithLabelValues(namespace, name, specHash).Set(1)
+}
+
+// ForgetIngressConfig removes the series of an IngressConfig that no longer exists.
+func ForgetIngressConfig(namespace, name string) {
+	labels := prometheus.Labels{labelNamespace: namespace, labelIngressConfig: name}
+	ProtectedIngresses.DeletePartialMatch(labels)
+	OutdatedIngresses.DeletePartialMatch(labels)
+	RolloutDuration.DeletePartialMatch(labels)
+	SnippetSize.DeletePartialMatch(labels)
+	IngressConfigInfo.DeletePartialMatch(labels)
+}
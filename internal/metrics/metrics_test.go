@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+)
+
+const operatorNamespace = "kube-botblocker"
+
+func TestSetIngressConfigState(t *testing.T) {
+	SetIngressConfigState(operatorNamespace, "state", "old-hash", 3, 1, 120)
+	SetIngressConfigState(operatorNamespace, "state", "new-hash", 3, 2, 150)
+
+	if got := testutil.ToFloat64(ProtectedIngresses.WithLabelValues(operatorNamespace, "state")); got != 3 {
+		t.Errorf("protected ingresses = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(OutdatedIngresses.WithLabelValues(operatorNamespace, "state")); got != 1 {
+		t.Errorf("outdated ingresses = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(SnippetSize.WithLabelValues(operatorNamespace, "state")); got != 150 {
+		t.Errorf("snippet size = %v, want 150", got)
+	}
+
+	expected := `
+# HELP kube_botblocker_ingressconfig_info Information about the IngressConfig. Always 1.
+# TYPE kube_botblocker_ingressconfig_info gauge
+kube_botblocker_ingressconfig_info{ingressconfig="state",namespace="kube-botblocker",spec_hash="new-hash"} 1
+`
+	if err := testutil.CollectAndCompare(IngressConfigInfo, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	ForgetIngressConfig(operatorNamespace, "state")
+	for name, count := range map[string]int{
+		"protected": testutil.CollectAndCount(ProtectedIngresses),
+		"outdated":  testutil.CollectAndCount(OutdatedIngresses),
+		"size":      testutil.CollectAndCount(SnippetSize),
+		"info":      testutil.CollectAndCount(IngressConfigInfo),
+	} {
+		if count != 0 {
+			t.Errorf("%s: %d series left after ForgetIngressConfig, want 0", name, count)
+		}
+	}
+}
+
+func TestOrphanCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := func(name string, ann map[string]string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "web", Annotations: ann}}
+	}
+	referencing := func(ingressConfig string) map[string]string {
+		return map[string]string{annotations.IngressConfigNameAnnotation: ingressConfig}
+	}
+	objs := []runtime.Object{
+		&v1beta1.IngressConfig{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: operatorNamespace}},
+		&v1beta1.IngressConfig{ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "other"}},
+		ingress("protected", referencing("existing")),
+		ingress("unprotected", nil),
+		ingress("empty-reference", referencing("")),
+		ingress("orphan-1", referencing("missing")),
+		ingress("orphan-2", referencing("missing")),
+		ingress("orphan-3", referencing("elsewhere")),
+	}
+
+	collector := NewOrphanCollector(fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build(),
+		operatorNamespace)
+
+	expected := `
+# HELP kube_botblocker_orphaned_ingress_references Number of Ingresses referencing an IngressConfig that doesn't exist in the operator namespace.
+# TYPE kube_botblocker_orphaned_ingress_references gauge
+kube_botblocker_orphaned_ingress_references{ingressconfig="elsewhere"} 1
+kube_botblocker_orphaned_ingress_references{ingressconfig="missing"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+)
+
+var orphanedReferencesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(metricsNamespace, "", "orphaned_ingress_references"),
+	"Number of Ingresses referencing an IngressConfig that doesn't exist in the operator namespace.",
+	[]string{labelIngressConfig},
+	nil,
+)
+
+// collectTimeout bounds the cache reads done on each scrape.
+const collectTimeout = 5 * time.Second
+
+// OrphanCollector counts, on every scrape, the Ingresses referencing an IngressConfig that doesn't exist.
+// Orphaned references never reach the IngressConfig controller, so they are counted from the cache instead.
+type OrphanCollector struct {
+	Reader            client.Reader
+	OperatorNamespace string
+}
+
+// NewOrphanCollector returns an OrphanCollector reading from the given (usually cached) reader.
+func NewOrphanCollector(reader client.Reader, operatorNamespace string) *OrphanCollector {
+	return &OrphanCollector{Reader: reader, OperatorNamespace: operatorNamespace}
+}
+
+func (c *OrphanCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- orphanedReferencesDesc
+}
+
+func (c *OrphanCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	orphans, err := c.orphanedReferences(ctx)
+	if err != nil {
+		logf.Log.WithName("metrics").Error(err, "Failed counting orphaned Ingress references")
+		ch <- prometheus.NewInvalidMetric(orphanedReferencesDesc, err)
+		return
+	}
+
+	for name, count := range orphans {
+		ch <- prometheus.MustNewConstMetric(orphanedReferencesDesc, prometheus.GaugeValue, float64(count), name)
+	}
+}
+
+func (c *OrphanCollector) orphanedReferences(ctx context.Context) (map[string]int, error) {
+	var ingressConfigs v1beta1.IngressConfigList
+	if err := c.Reader.List(ctx, &ingressConfigs, client.InNamespace(c.OperatorNamespace)); err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(ingressConfigs.Items))
+	for _, ic := range ingressConfigs.Items {
+		existing[ic.Name] = true
+	}
+
+	var ingresses networkingv1.IngressList
+	if err := c.Reader.List(ctx, &ingresses); err != nil {
+		return nil, err
+	}
+
+	orphans := make(map[string]int)
+	for _, ing := range ingresses.Items {
+		// An empty reference is handled as no reference at all
+		name := ing.GetAnnotations()[annotations.IngressConfigNameAnnotation]
+		if name != "" && !existing[name] {
+			orphans[name]++
+		}
+	}
+	return orphans, nil
+}
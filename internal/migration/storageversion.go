@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+var ingressConfigCRDName = "ingressconfigs." + v1beta1.GroupVersion.Group
+
+// StorageVersionMigrator rewrites every IngressConfig in the current storage version and then removes older
+// versions from the status.storedVersions field of the CRD, allowing them to be dropped from the CRD in a later release.
+// It runs once on startup and never fails the manager: if anything goes wrong, migration is retried on the next start.
+type StorageVersionMigrator struct {
+	Client client.Client
+	// Reader reads directly from the API server, so IngressConfigs outside of the cached namespaces are migrated too.
+	Reader client.Reader
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update;patch
+
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("storageVersionMigrator")
+	storageVersion := v1beta1.GroupVersion.Version
+
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "apiextensions.k8s.io",
+		Version: "v1",
+		Kind:    "CustomResourceDefinition",
+	})
+	if err := m.Reader.Get(ctx, client.ObjectKey{Name: ingressConfigCRDName}, crd); err != nil {
+		log.Error(err, "Failed fetching IngressConfig CRD; skipping storage version migration")
+		return nil
+	}
+
+	storedVersions, _, _ := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	if len(storedVersions) == 1 && storedVersions[0] == storageVersion {
+		return nil
+	}
+
+	var ingressConfigList v1beta1.IngressConfigList
+	if err := m.Reader.List(ctx, &ingressConfigList); err != nil {
+		log.Error(err, "Failed listing IngressConfigs; skipping storage version migration")
+		return nil
+	}
+
+	for _, ingressConfig := range ingressConfigList.Items {
+		// An update without changes is enough for the API server to write the object back in the storage version
+		if err := m.Client.Update(ctx, &ingressConfig); err != nil {
+			log.Error(err, "Failed migrating IngressConfig; skipping storage version migration",
+				"ingressConfig", client.ObjectKeyFromObject(&ingressConfig))
+			return nil
+		}
+	}
+
+	if err := unstructured.SetNestedStringSlice(crd.Object, []string{storageVersion}, "status", "storedVersions"); err != nil {
+		log.Error(err, "Failed setting IngressConfig CRD stored versions")
+		return nil
+	}
+	if err := m.Client.Status().Update(ctx, crd); err != nil {
+		log.Error(err, "Failed updating IngressConfig CRD stored versions")
+		return nil
+	}
+
+	log.Info("IngressConfigs migrated to storage version", "version", storageVersion, "count", len(ingressConfigList.Items))
+	return nil
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+	"github.com/GustavoJST/kube-botblocker/pkg/environment"
+	"github.com/GustavoJST/kube-botblocker/pkg/nginx"
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var ingresslog = logf.Log.WithName("ingress-resource")
+
+// SetupIngressWebhookWithManager registers the webhook for Ingress in the manager.
+func SetupIngressWebhookWithManager(mgr ctrl.Manager, env *environment.OperatorEnv) error {
+	// The operator can't tell its own changes from anyone else's without knowing who it authenticates as
+	if env.OperatorUsername() == "" {
+		ingresslog.Info("OPERATOR_SERVICE_ACCOUNT is not set; changes to the generated configuration of " +
+			"Ingresses made outside of the operator won't be denied")
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).For(&networkingv1.Ingress{}).
+		WithValidator(&IngressCustomValidator{Client: mgr.GetClient(), Environment: env}).
+		WithDefaulter(&IngressCustomDefaulter{Client: mgr.GetClient(), Environment: env}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-networking-k8s-io-v1-ingress,mutating=true,failurePolicy=ignore,sideEffects=None,groups=networking.k8s.io,resources=ingresses,verbs=create;update,versions=v1,name=mingress-v1.kb.io,admissionReviewVersions=v1
+
+// IngressCustomDefaulter inserts the operator block into the server-snippet annotation of
+// protected Ingresses at admission time, so they are never stored without it and don't need
+// to be updated again by the operator. The IngressReconciler still applies the block to
+// Ingresses admitted while the webhook was unavailable.
+type IngressCustomDefaulter struct {
+	Client      client.Reader
+	Environment *environment.OperatorEnv
+}
+
+var _ webhook.CustomDefaulter = &IngressCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type Ingress.
+func (d *IngressCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	ingress, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return fmt.Errorf("expected an Ingress object but got %T", obj)
+	}
+	ingresslog.V(1).Info("Defaulting for Ingress", "name", ingress.GetName())
+
+	ann := ingress.GetAnnotations()
+	snippetAnnotation := d.Environment.SnippetAnnotation()
+	rendererVersion := strconv.Itoa(int(snippet.RendererVersion))
+	block, specHash, err := desiredBlock(ctx, d.Client, d.Environment, ingress)
+	if err != nil {
+		return err
+	}
+	if block == "" && ann[annotations.IngressConfigNameAnnotation] != "" {
+		// The IngressReconciler leaves the block of paused Ingresses and of suspended or not yet reconciled
+		// IngressConfigs as it is, so it's carried over when the request drops it, instead of being denied.
+		block, specHash, rendererVersion = storedBlock(ctx, snippetAnnotation, ann[snippetAnnotation])
+	}
+	if block == "" {
+		return nil
+	}
+
+	updatedSnippet, err := snippet.Update(ann[snippetAnnotation], block)
+	if err != nil {
+		// Broken markers are reported by the IngressReconciler, admission must not be blocked by them.
+		ingresslog.Info("Skipping server-snippet injection", "name", ingress.GetName(), "reason", err.Error())
+		return nil
+	}
+	if err := nginx.Validate(updatedSnippet); err != nil {
+		// Same for invalid NGINX configuration, which the IngressReconciler refuses to write.
+		ingresslog.Info("Skipping server-snippet injection", "name", ingress.GetName(), "reason", err.Error())
+		return nil
+	}
+
+	// Same as the IngressReconciler, so the original snippet can be restored exactly on removal
+	if current := ann[snippetAnnotation]; current != "" {
+		if currentBlock, _ := snippet.Extract(current); currentBlock == "" {
+			ann[annotations.IngressOriginalSnippetHash] = snippet.Hash(current)
+		}
+	}
+	ann[snippetAnnotation] = updatedSnippet
+	if specHash != "" {
+		ann[annotations.IngressConfigSpecHash] = specHash
+	}
+	if rendererVersion != "" {
+		ann[annotations.IngressRendererVersion] = rendererVersion
+	}
+	return nil
+}
+
+// storedBlock returns the operator block of the stored Ingress being updated, along with the spec hash and
+// renderer version it was rendered with, when currentSnippet has no markers at all. An empty block is
+// returned otherwise, and on creation.
+func storedBlock(ctx context.Context, snippetAnnotation, currentSnippet string) (block, specHash, rendererVersion string) {
+	if snippet.HasMarkers(currentSnippet) {
+		return "", "", ""
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || len(req.OldObject.Raw) == 0 {
+		return "", "", ""
+	}
+	var oldIngress networkingv1.Ingress
+	if err := json.Unmarshal(req.OldObject.Raw, &oldIngress); err != nil {
+		return "", "", ""
+	}
+
+	oldAnn := oldIngress.GetAnnotations()
+	block, ok := snippet.Extract(oldAnn[snippetAnnotation])
+	if !ok {
+		return "", "", ""
+	}
+	return block, oldAnn[annotations.IngressConfigSpecHash], oldAnn[annotations.IngressRendererVersion]
+}
+
+// +kubebuilder:webhook:path=/validate-networking-k8s-io-v1-ingress,mutating=false,failurePolicy=ignore,sideEffects=None,groups=networking.k8s.io,resources=ingresses,verbs=create;update,versions=v1,name=vingress-v1.kb.io,admissionReviewVersions=v1
+
+// IngressCustomValidator validates the kube-botblocker annotations of Ingress objects,
+// including whether the referenced IngressConfig exists and may be used from the Ingress namespace,
+// and protects the operator block of the server-snippet annotation from changes by other users.
+type IngressCustomValidator struct {
+	Client      client.Reader
+	Environment *environment.OperatorEnv
+}
+
+var _ webhook.CustomValidator = &IngressCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Ingress.
+func (v *IngressCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ingress, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("expected a Ingress object but got %T", obj)
+	}
+	ingresslog.V(1).Info("Validation for Ingress upon creation", "name", ingress.GetName())
+
+	return v.validateIngress(ctx, nil, ingress)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Ingress.
+func (v *IngressCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldIngress, ok := oldObj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("expected a Ingress object for the oldObj but got %T", oldObj)
+	}
+	ingress, ok := newObj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("expected a Ingress object for the newObj but got %T", newObj)
+	}
+	ingresslog.V(1).Info("Validation for Ingress upon update", "name", ingress.GetName())
+
+	if err := v.validateSnippetBlock(ctx, oldIngress, ingress); err != nil {
+		return nil, err
+	}
+
+	return v.validateIngress(ctx, oldIngress, ingress)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Ingress.
+func (v *IngressCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateIngress checks the kube-botblocker annotations of ingress. References are only checked
+// when they are added or changed, so Ingresses referencing an IngressConfig that was deleted
+// afterwards can still be updated.
+func (v *IngressCustomValidator) validateIngress(
+	ctx context.Context,
+	oldIngress, ingress *networkingv1.Ingress,
+) (admission.Warnings, error) {
+	if v.Environment.CurrentNamespaceOnly && ingress.Namespace != v.Environment.OperatorNamespace {
+		return nil, nil
+	}
+	if !ingress.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	var (
+		allErrs        field.ErrorList
+		annotationPath = field.NewPath("metadata", "annotations")
+		ann            = ingress.GetAnnotations()
+	)
+
+	if paused, ok := ann[annotations.IngressPaused]; ok && paused != "true" && paused != "false" {
+		allErrs = append(allErrs, field.Invalid(annotationPath.Key(annotations.IngressPaused), paused,
+			`must be "true" or "false"`))
+	}
+
+	name, protected := ann[annotations.IngressConfigNameAnnotation]
+	if protected && (oldIngress == nil || oldIngress.GetAnnotations()[annotations.IngressConfigNameAnnotation] != name) {
+		refErrs, err := v.validateReference(ctx, ingress.Namespace, name,
+			annotationPath.Key(annotations.IngressConfigNameAnnotation))
+		if err != nil {
+			return nil, err
+		}
+		allErrs = append(allErrs, refErrs...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil, nil
+	}
+
+	if v.Environment.IngressReferenceValidation == environment.ReferenceValidationWarn {
+		warnings := make(admission.Warnings, 0, len(allErrs))
+		for _, fieldErr := range allErrs {
+			warnings = append(warnings, fieldErr.Error())
+		}
+		return warnings, nil
+	}
+
+	return nil, apierrors.NewInvalid(
+		schema.GroupKind{Group: networkingv1.GroupName, Kind: "Ingress"}, ingress.Name, allErrs)
+}
+
+// validateSnippetBlock denies changes to the operator block of the annotation the operator writes to
+// that don't come from the operator itself. Content around the block can be changed freely.
+// Blocks with broken markers are not protected, so they can be repaired by hand.
+func (v *IngressCustomValidator) validateSnippetBlock(ctx context.Context, oldIngress, ingress *networkingv1.Ingress) error {
+	operatorUsername := v.Environment.OperatorUsername()
+	if operatorUsername == "" {
+		return nil
+	}
+	if v.Environment.CurrentNamespaceOnly && ingress.Namespace != v.Environment.OperatorNamespace {
+		return nil
+	}
+
+	snippetAnnotation := v.Environment.SnippetAnnotation()
+	oldBlock, ok := snippet.Extract(oldIngress.GetAnnotations()[snippetAnnotation])
+	if !ok {
+		return nil
+	}
+	newBlock, ok := snippet.Extract(ingress.GetAnnotations()[snippetAnnotation])
+	if ok && newBlock == oldBlock {
+		return nil
+	}
+
+	// Blocks inserted by the IngressCustomDefaulter are part of the user request.
+	if ok && newBlock != "" {
+		expectedBlock, _, err := desiredBlock(ctx, v.Client, v.Environment, ingress)
+		if err != nil {
+			return err
+		}
+		if newBlock == expectedBlock {
+			return nil
+		}
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if req.UserInfo.Username == operatorUsername {
+		return nil
+	}
+
+	return apierrors.NewForbidden(
+		schema.GroupResource{Group: networkingv1.GroupName, Resource: "ingresses"}, ingress.Name,
+		fmt.Errorf("the configuration between the %q and %q markers of the %s annotation is managed by kube-botblocker "+
+			"and can't be changed or removed. Remove the %s annotation to have it removed by the operator",
+			strings.TrimSpace(snippet.StartMarker), snippet.EndMarker, snippetAnnotation,
+			annotations.IngressConfigNameAnnotation),
+	)
+}
+
+func (v *IngressCustomValidator) validateReference(
+	ctx context.Context,
+	namespace, name string,
+	path *field.Path,
+) (field.ErrorList, error) {
+	if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+		return field.ErrorList{field.Invalid(path, name, strings.Join(msgs, "; "))}, nil
+	}
+
+	var ingressConfig v1beta1.IngressConfig
+	key := types.NamespacedName{Namespace: v.Environment.OperatorNamespace, Name: name}
+	if err := v.Client.Get(ctx, key, &ingressConfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.ErrorList{field.NotFound(path, name)}, nil
+		}
+		return nil, err
+	}
+
+	// Namespace labels can only be read when the operator watches all namespaces.
+	if v.Environment.CurrentNamespaceOnly {
+		return nil, nil
+	}
+
+	var ns corev1.Namespace
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return nil, err
+	}
+	allowed, err := ingressConfig.Spec.AllowsNamespace(ns.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return field.ErrorList{field.Forbidden(path, fmt.Sprintf(
+			"IngressConfig %q can't be used from namespace %q, which doesn't match its namespaceSelector", name, namespace))}, nil
+	}
+
+	return nil, nil
+}
+
+// desiredBlock returns the operator block the Ingress should have and the spec hash of the
+// IngressConfig it was rendered from. An empty block is returned when the Ingress isn't
+// protected, or when the IngressReconciler would leave it untouched.
+func desiredBlock(
+	ctx context.Context,
+	reader client.Reader,
+	env *environment.OperatorEnv,
+	ingress *networkingv1.Ingress,
+) (block, specHash string, err error) {
+	if env.CurrentNamespaceOnly && ingress.Namespace != env.OperatorNamespace {
+		return "", "", nil
+	}
+
+	ann := ingress.GetAnnotations()
+	name := ann[annotations.IngressConfigNameAnnotation]
+	if name == "" || ann[annotations.IngressPaused] == "true" {
+		return "", "", nil
+	}
+
+	var ingressConfig v1beta1.IngressConfig
+	key := types.NamespacedName{Namespace: env.OperatorNamespace, Name: name}
+	if err := reader.Get(ctx, key, &ingressConfig); err != nil {
+		return "", "", client.IgnoreNotFound(err)
+	}
+
+	// The spec hash in the status only matches the spec once the IngressConfig has been reconciled.
+	if ingressConfig.Spec.Suspend || ingressConfig.Status.SpecHash == "" ||
+		ingressConfig.Status.ObservedGeneration != ingressConfig.Generation {
+		return "", "", nil
+	}
+
+	if ingressConfig.Spec.NamespaceSelector != nil && !env.CurrentNamespaceOnly {
+		var ns corev1.Namespace
+		if err := reader.Get(ctx, types.NamespacedName{Name: ingress.Namespace}, &ns); err != nil {
+			return "", "", err
+		}
+		allowed, err := ingressConfig.Spec.AllowsNamespace(ns.Labels)
+		if err != nil || !allowed {
+			return "", "", nil
+		}
+	}
+
+	block = snippet.Build(ingressConfig.Spec.Patterns(), ingressConfig.Spec.Response.GetStatusCode())
+	return block, ingressConfig.Status.SpecHash, nil
+}
@@ -0,0 +1,529 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+	"github.com/GustavoJST/kube-botblocker/pkg/environment"
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
+)
+
+const operatorNamespace = "kube-botblocker"
+
+func newValidator(t *testing.T, mode environment.ReferenceValidationMode) *IngressCustomValidator {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"team": "web"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "data", Labels: map[string]string{"team": "data"}}},
+		&v1beta1.IngressConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "open", Namespace: operatorNamespace},
+			Spec: v1beta1.IngressConfigSpec{
+				BlockedUserAgents: []v1beta1.UserAgentRule{{Pattern: "GoogleBot"}},
+			},
+			Status: v1beta1.IngressConfigStatus{SpecHash: "open-hash"},
+		},
+		&v1beta1.IngressConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "suspended", Namespace: operatorNamespace},
+			Spec: v1beta1.IngressConfigSpec{
+				BlockedUserAgents: []v1beta1.UserAgentRule{{Pattern: "GoogleBot"}},
+				Suspend:           true,
+			},
+			Status: v1beta1.IngressConfigStatus{SpecHash: "suspended-hash"},
+		},
+		&v1beta1.IngressConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: operatorNamespace},
+			Spec: v1beta1.IngressConfigSpec{
+				BlockedUserAgents: []v1beta1.UserAgentRule{{Pattern: "GoogleBot"}},
+			},
+		},
+		&v1beta1.IngressConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-only", Namespace: operatorNamespace},
+			Spec: v1beta1.IngressConfigSpec{
+				BlockedUserAgents: []v1beta1.UserAgentRule{{Pattern: "GoogleBot"}},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+			},
+			Status: v1beta1.IngressConfigStatus{SpecHash: "web-only-hash"},
+		},
+	}
+
+	return &IngressCustomValidator{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build(),
+		Environment: &environment.OperatorEnv{
+			OperatorNamespace:          operatorNamespace,
+			IngressReferenceValidation: mode,
+		},
+	}
+}
+
+func newIngress(namespace string, ann map[string]string) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: namespace, Annotations: ann},
+	}
+}
+
+func TestIngressCustomValidatorCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ingress *networkingv1.Ingress
+		wantErr bool
+	}{
+		{
+			name:    "No annotations",
+			ingress: newIngress("web", nil),
+		},
+		{
+			name:    "Existing IngressConfig",
+			ingress: newIngress("data", map[string]string{annotations.IngressConfigNameAnnotation: "open"}),
+		},
+		{
+			name:    "Namespace allowed by selector",
+			ingress: newIngress("web", map[string]string{annotations.IngressConfigNameAnnotation: "web-only"}),
+		},
+		{
+			name:    "Namespace not allowed by selector",
+			ingress: newIngress("data", map[string]string{annotations.IngressConfigNameAnnotation: "web-only"}),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown IngressConfig",
+			ingress: newIngress("web", map[string]string{annotations.IngressConfigNameAnnotation: "missing"}),
+			wantErr: true,
+		},
+		{
+			name:    "Malformed IngressConfig name",
+			ingress: newIngress("web", map[string]string{annotations.IngressConfigNameAnnotation: "Not A Name"}),
+			wantErr: true,
+		},
+		{
+			name:    "Malformed paused annotation",
+			ingress: newIngress("web", map[string]string{annotations.IngressPaused: "yes"}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newValidator(t, environment.ReferenceValidationReject).ValidateCreate(context.Background(), tt.ingress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCreate() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+			if err != nil && !apierrors.IsInvalid(err) {
+				t.Errorf("ValidateCreate() error - got: %v, expected an Invalid error", err)
+			}
+
+			warnings, err := newValidator(t, environment.ReferenceValidationWarn).ValidateCreate(context.Background(), tt.ingress)
+			if err != nil {
+				t.Errorf("ValidateCreate() in Warn mode error - got: %v, expected no error", err)
+			}
+			if (len(warnings) > 0) != tt.wantErr {
+				t.Errorf("ValidateCreate() in Warn mode warnings - got: %v, expected warnings: %v", warnings, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIngressCustomValidatorUpdate(t *testing.T) {
+	validator := newValidator(t, environment.ReferenceValidationReject)
+	oldIngress := newIngress("web", map[string]string{annotations.IngressConfigNameAnnotation: "missing"})
+
+	unchanged := oldIngress.DeepCopy()
+	unchanged.Labels = map[string]string{"app": "web"}
+	if _, err := validator.ValidateUpdate(context.Background(), oldIngress, unchanged); err != nil {
+		t.Errorf("ValidateUpdate() error - got: %v, expected no error when the reference is unchanged", err)
+	}
+
+	changed := oldIngress.DeepCopy()
+	changed.Annotations[annotations.IngressConfigNameAnnotation] = "also-missing"
+	if _, err := validator.ValidateUpdate(context.Background(), oldIngress, changed); err == nil {
+		t.Error("ValidateUpdate() error - got: nil, expected an error when the reference changes to an unknown IngressConfig")
+	}
+}
+
+func TestIngressCustomValidatorCurrentNamespaceOnly(t *testing.T) {
+	validator := newValidator(t, environment.ReferenceValidationReject)
+	validator.Environment.CurrentNamespaceOnly = true
+
+	ingress := newIngress("web", map[string]string{annotations.IngressConfigNameAnnotation: "missing"})
+	if _, err := validator.ValidateCreate(context.Background(), ingress); err != nil {
+		t.Errorf("ValidateCreate() error - got: %v, expected Ingresses outside the operator namespace to be ignored", err)
+	}
+}
+
+func TestIngressCustomValidatorSnippetBlock(t *testing.T) {
+	const operatorUsername = "system:serviceaccount:kube-botblocker:operator"
+
+	var (
+		block    = snippet.Build([]string{"GoogleBot"}, 403)
+		newBlock = snippet.Build([]string{"AI2Bot"}, 403)
+		userConf = "location /foo {\n  return 200;\n}"
+	)
+
+	tests := []struct {
+		name       string
+		oldSnippet string
+		newSnippet string
+		reference  string
+		username   string
+		wantErr    bool
+	}{
+		{
+			name:       "User changes content around the block",
+			oldSnippet: userConf + "\n\n" + block,
+			newSnippet: "# user comment\n\n" + block,
+			username:   "jane",
+		},
+		{
+			name:       "User changes the block",
+			oldSnippet: userConf + "\n\n" + block,
+			newSnippet: userConf + "\n\n" + newBlock,
+			username:   "jane",
+			wantErr:    true,
+		},
+		{
+			name:       "User removes the block",
+			oldSnippet: userConf + "\n\n" + block,
+			newSnippet: userConf,
+			username:   "jane",
+			wantErr:    true,
+		},
+		{
+			name:       "User breaks the end marker",
+			oldSnippet: block,
+			newSnippet: snippet.StartMarker + "return 403;",
+			username:   "jane",
+			wantErr:    true,
+		},
+		{
+			name:       "User adds a block",
+			oldSnippet: userConf,
+			newSnippet: userConf + "\n\n" + block,
+			username:   "jane",
+			wantErr:    true,
+		},
+		{
+			name:       "Block injected on behalf of the user",
+			oldSnippet: userConf,
+			newSnippet: userConf + "\n\n" + block,
+			reference:  "open",
+			username:   "jane",
+		},
+		{
+			name:       "User repairs broken markers",
+			oldSnippet: userConf + "\n\n" + snippet.StartMarker,
+			newSnippet: userConf,
+			username:   "jane",
+		},
+		{
+			name:       "Operator changes the block",
+			oldSnippet: userConf + "\n\n" + block,
+			newSnippet: userConf + "\n\n" + newBlock,
+			username:   operatorUsername,
+		},
+		{
+			name:       "Operator removes the block",
+			oldSnippet: userConf + "\n\n" + block,
+			newSnippet: userConf,
+			username:   operatorUsername,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newValidator(t, environment.ReferenceValidationReject)
+			validator.Environment.OperatorServiceAccount = "operator"
+
+			oldIngress := newIngress("web", map[string]string{annotations.IngressServerSnippet: tt.oldSnippet})
+			ingress := newIngress("web", map[string]string{annotations.IngressServerSnippet: tt.newSnippet})
+			if tt.reference != "" {
+				ingress.Annotations[annotations.IngressConfigNameAnnotation] = tt.reference
+			}
+
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: tt.username},
+				},
+			})
+
+			_, err := validator.ValidateUpdate(ctx, oldIngress, ingress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateUpdate() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+			if err != nil && !apierrors.IsForbidden(err) {
+				t.Errorf("ValidateUpdate() error - got: %v, expected a Forbidden error", err)
+			}
+		})
+	}
+}
+
+func TestIngressCustomDefaulter(t *testing.T) {
+	var (
+		block    = snippet.Build([]string{"GoogleBot"}, 403)
+		userConf = "location /foo {\n  return 200;\n}"
+	)
+
+	tests := []struct {
+		name        string
+		namespace   string
+		ann         map[string]string
+		wantSnippet string
+		wantHash    string
+	}{
+		{
+			name:        "Not protected",
+			namespace:   "web",
+			ann:         map[string]string{annotations.IngressServerSnippet: userConf},
+			wantSnippet: userConf,
+		},
+		{
+			name:        "Protected without snippet",
+			namespace:   "web",
+			ann:         map[string]string{annotations.IngressConfigNameAnnotation: "open"},
+			wantSnippet: block,
+			wantHash:    "open-hash",
+		},
+		{
+			name:      "Protected with existing snippet",
+			namespace: "web",
+			ann: map[string]string{
+				annotations.IngressConfigNameAnnotation: "open",
+				annotations.IngressServerSnippet:        userConf,
+			},
+			wantSnippet: userConf + "\n\n" + block,
+			wantHash:    "open-hash",
+		},
+		{
+			name:      "Paused",
+			namespace: "web",
+			ann: map[string]string{
+				annotations.IngressConfigNameAnnotation: "open",
+				annotations.IngressPaused:               "true",
+			},
+		},
+		{
+			name:      "Unknown IngressConfig",
+			namespace: "web",
+			ann:       map[string]string{annotations.IngressConfigNameAnnotation: "missing"},
+		},
+		{
+			name:      "Suspended IngressConfig",
+			namespace: "web",
+			ann:       map[string]string{annotations.IngressConfigNameAnnotation: "suspended"},
+		},
+		{
+			name:      "IngressConfig not reconciled yet",
+			namespace: "web",
+			ann:       map[string]string{annotations.IngressConfigNameAnnotation: "pending"},
+		},
+		{
+			name:        "Namespace allowed by selector",
+			namespace:   "web",
+			ann:         map[string]string{annotations.IngressConfigNameAnnotation: "web-only"},
+			wantSnippet: block,
+			wantHash:    "web-only-hash",
+		},
+		{
+			name:      "Namespace not allowed by selector",
+			namespace: "data",
+			ann:       map[string]string{annotations.IngressConfigNameAnnotation: "web-only"},
+		},
+		{
+			name:      "Broken markers",
+			namespace: "web",
+			ann: map[string]string{
+				annotations.IngressConfigNameAnnotation: "open",
+				annotations.IngressServerSnippet:        snippet.StartMarker,
+			},
+			wantSnippet: snippet.StartMarker,
+		},
+		{
+			name:      "Invalid NGINX configuration",
+			namespace: "web",
+			ann: map[string]string{
+				annotations.IngressConfigNameAnnotation: "open",
+				annotations.IngressServerSnippet:        "location /foo {\n  return 200\n}",
+			},
+			wantSnippet: "location /foo {\n  return 200\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newValidator(t, environment.ReferenceValidationReject)
+			defaulter := &IngressCustomDefaulter{Client: validator.Client, Environment: validator.Environment}
+
+			ingress := newIngress(tt.namespace, tt.ann)
+			if err := defaulter.Default(context.Background(), ingress); err != nil {
+				t.Fatalf("Default() error - got: %v, expected no error", err)
+			}
+			if got := ingress.Annotations[annotations.IngressServerSnippet]; got != tt.wantSnippet {
+				t.Errorf("Default() server-snippet - got: %q, expected: %q", got, tt.wantSnippet)
+			}
+			if got := ingress.Annotations[annotations.IngressConfigSpecHash]; got != tt.wantHash {
+				t.Errorf("Default() spec hash - got: %q, expected: %q", got, tt.wantHash)
+			}
+			_, hasRendererVersion := ingress.Annotations[annotations.IngressRendererVersion]
+			if hasRendererVersion != (tt.wantHash != "") {
+				t.Errorf("Default() renderer version - got: %v, expected: %v", hasRendererVersion, tt.wantHash != "")
+			}
+		})
+	}
+}
+
+func TestIngressCustomDefaulterCarriesOverBlock(t *testing.T) {
+	var (
+		block    = snippet.Build([]string{"AI2Bot"}, 403)
+		userConf = "location /foo {\n  return 200;\n}"
+	)
+
+	tests := []struct {
+		name        string
+		reference   string
+		paused      bool
+		newSnippet  string
+		wantSnippet string
+	}{
+		{
+			name:        "Suspended IngressConfig",
+			reference:   "suspended",
+			newSnippet:  userConf,
+			wantSnippet: userConf + "\n\n" + block,
+		},
+		{
+			name:        "IngressConfig not reconciled yet",
+			reference:   "pending",
+			newSnippet:  userConf,
+			wantSnippet: userConf + "\n\n" + block,
+		},
+		{
+			name:        "Paused",
+			reference:   "open",
+			paused:      true,
+			newSnippet:  userConf,
+			wantSnippet: userConf + "\n\n" + block,
+		},
+		{
+			name:        "Reference removed",
+			newSnippet:  userConf,
+			wantSnippet: userConf,
+		},
+		{
+			name:        "Block changed by the request",
+			reference:   "suspended",
+			newSnippet:  userConf + "\n\n" + snippet.Build([]string{"GoogleBot"}, 200),
+			wantSnippet: userConf + "\n\n" + snippet.Build([]string{"GoogleBot"}, 200),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newValidator(t, environment.ReferenceValidationReject)
+			validator.Environment.OperatorServiceAccount = "operator"
+			defaulter := &IngressCustomDefaulter{Client: validator.Client, Environment: validator.Environment}
+
+			oldAnn := map[string]string{
+				annotations.IngressServerSnippet:   block,
+				annotations.IngressConfigSpecHash:  "old-hash",
+				annotations.IngressRendererVersion: "2",
+			}
+			ann := map[string]string{annotations.IngressServerSnippet: tt.newSnippet}
+			if tt.reference != "" {
+				oldAnn[annotations.IngressConfigNameAnnotation] = tt.reference
+				ann[annotations.IngressConfigNameAnnotation] = tt.reference
+			}
+			if tt.paused {
+				oldAnn[annotations.IngressPaused] = "true"
+				ann[annotations.IngressPaused] = "true"
+			}
+			oldIngress := newIngress("web", oldAnn)
+			ingress := newIngress("web", ann)
+
+			raw, err := json.Marshal(oldIngress)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Update,
+					OldObject: runtime.RawExtension{Raw: raw},
+					UserInfo:  authenticationv1.UserInfo{Username: "jane"},
+				},
+			})
+
+			if err := defaulter.Default(ctx, ingress); err != nil {
+				t.Fatalf("Default() error - got: %v, expected no error", err)
+			}
+			if got := ingress.Annotations[annotations.IngressServerSnippet]; got != tt.wantSnippet {
+				t.Errorf("Default() server-snippet - got: %q, expected: %q", got, tt.wantSnippet)
+			}
+			if tt.wantSnippet != tt.newSnippet {
+				if got := ingress.Annotations[annotations.IngressConfigSpecHash]; got != "old-hash" {
+					t.Errorf("Default() spec hash - got: %q, expected: %q", got, "old-hash")
+				}
+				if got := ingress.Annotations[annotations.IngressRendererVersion]; got != "2" {
+					t.Errorf("Default() renderer version - got: %q, expected: %q", got, "2")
+				}
+				if _, err := validator.ValidateUpdate(ctx, oldIngress, ingress); err != nil {
+					t.Errorf("ValidateUpdate() error - got: %v, expected no error", err)
+				}
+			}
+		})
+	}
+}
+
+func TestIngressCustomDefaulterAnnotationMode(t *testing.T) {
+	block := snippet.Build([]string{"GoogleBot"}, 403)
+	userConf := "location /foo {\n  return 200;\n}"
+
+	validator := newValidator(t, environment.ReferenceValidationReject)
+	validator.Environment.SnippetMode = environment.SnippetModeAnnotation
+	defaulter := &IngressCustomDefaulter{Client: validator.Client, Environment: validator.Environment}
+
+	ingress := newIngress("web", map[string]string{
+		annotations.IngressConfigNameAnnotation: "open",
+		annotations.IngressServerSnippet:        userConf,
+	})
+	if err := defaulter.Default(context.Background(), ingress); err != nil {
+		t.Fatalf("Default() error - got: %v, expected no error", err)
+	}
+	if got := ingress.Annotations[annotations.IngressServerSnippet]; got != userConf {
+		t.Errorf("Default() server-snippet - got: %q, expected: %q", got, userConf)
+	}
+	if got := ingress.Annotations[annotations.IngressGeneratedSnippet]; got != block {
+		t.Errorf("Default() generated snippet - got: %q, expected: %q", got, block)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kubebotblockergithubiov1beta1 "github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+	"github.com/GustavoJST/kube-botblocker/pkg/environment"
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var ingressconfiglog = logf.Log.WithName("ingressconfig-resource")
+
+// maxListedIngresses is the maximum number of referencing Ingresses listed when a deletion is denied.
+const maxListedIngresses = 10
+
+// SetupIngressConfigWebhookWithManager registers the webhook for IngressConfig in the manager.
+// IngressConfig v1beta1 is the conversion hub, so this also serves the conversion webhook for all
+// other versions of the resource.
+func SetupIngressConfigWebhookWithManager(mgr ctrl.Manager, env *environment.OperatorEnv) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&kubebotblockergithubiov1beta1.IngressConfig{}).
+		WithValidator(&IngressConfigCustomValidator{
+			Client:            mgr.GetClient(),
+			OperatorNamespace: env.OperatorNamespace,
+			MaxSnippetSize:    env.MaxSnippetSize,
+		}).
+		Complete()
+}
+
+// SetupIngressConfigConversionWithManager only serves the conversion webhook of IngressConfig, for when the
+// admission webhooks are disabled while the CRD still converts between the served versions through the operator.
+func SetupIngressConfigConversionWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&kubebotblockergithubiov1beta1.IngressConfig{}).Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-kube-botblocker-github-io-v1beta1-ingressconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=kube-botblocker.github.io,resources=ingressconfigs,verbs=create;update;delete,versions=v1beta1,name=vingressconfig-v1beta1.kb.io,admissionReviewVersions=v1
+
+// IngressConfigCustomValidator validates IngressConfig objects against the rendering rules
+// of the NGINX configuration generated for them, and denies the deletion of those still in use
+// when their deletion policy is Block.
+type IngressConfigCustomValidator struct {
+	Client client.Reader
+	// OperatorNamespace is the only namespace whose IngressConfigs can be referenced by Ingresses.
+	OperatorNamespace string
+	// MaxSnippetSize is the maximum size in bytes of the rendered configuration. Zero disables the check.
+	MaxSnippetSize int
+}
+
+var _ webhook.CustomValidator = &IngressConfigCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type IngressConfig.
+func (v *IngressConfigCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ingressconfig, ok := obj.(*kubebotblockergithubiov1beta1.IngressConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected a IngressConfig object but got %T", obj)
+	}
+	ingressconfiglog.V(1).Info("Validation for IngressConfig upon creation", "name", ingressconfig.GetName())
+
+	return v.validateIngressConfig(nil, ingressconfig)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type IngressConfig.
+func (v *IngressConfigCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldIngressConfig, ok := oldObj.(*kubebotblockergithubiov1beta1.IngressConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected a IngressConfig object for the oldObj but got %T", oldObj)
+	}
+	ingressconfig, ok := newObj.(*kubebotblockergithubiov1beta1.IngressConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected a IngressConfig object for the newObj but got %T", newObj)
+	}
+	ingressconfiglog.V(1).Info("Validation for IngressConfig upon update", "name", ingressconfig.GetName())
+
+	// Objects being deleted only need to have their finalizer removed, which must not be
+	// blocked by entries that were accepted before these rules existed.
+	if !ingressconfig.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	// Updates leaving the spec alone, such as finalizer changes or the storage migration, are not
+	// validated either, for the same reason.
+	if equality.Semantic.DeepEqual(oldIngressConfig.Spec, ingressconfig.Spec) {
+		return nil, nil
+	}
+
+	return v.validateIngressConfig(oldIngressConfig, ingressconfig)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type IngressConfig.
+func (v *IngressConfigCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ingressconfig, ok := obj.(*kubebotblockergithubiov1beta1.IngressConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected a IngressConfig object but got %T", obj)
+	}
+	ingressconfiglog.V(1).Info("Validation for IngressConfig upon deletion", "name", ingressconfig.GetName())
+
+	if ingressconfig.Spec.DeletionPolicy != kubebotblockergithubiov1beta1.DeletionPolicyBlock ||
+		ingressconfig.Namespace != v.OperatorNamespace {
+		return nil, nil
+	}
+
+	var ingressList networkingv1.IngressList
+	if err := v.Client.List(
+		ctx,
+		&ingressList,
+		client.MatchingFields{annotations.IngressConfigNameAnnotation: ingressconfig.Name},
+	); err != nil {
+		return nil, err
+	}
+	if len(ingressList.Items) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(ingressList.Items))
+	for _, ingress := range ingressList.Items {
+		names = append(names, ingress.Namespace+"/"+ingress.Name)
+	}
+	slices.Sort(names)
+	if len(names) > maxListedIngresses {
+		names = append(names[:maxListedIngresses], fmt.Sprintf("and %d more", len(names)-maxListedIngresses))
+	}
+
+	return nil, apierrors.NewForbidden(
+		kubebotblockergithubiov1beta1.GroupVersion.WithResource("ingressconfigs").GroupResource(),
+		ingressconfig.Name, fmt.Errorf(
+			"deletionPolicy is Block and the IngressConfig is still referenced by %d Ingresses: %s",
+			len(ingressList.Items), strings.Join(names, ", ")))
+}
+
+// validateIngressConfig validates the rules of ingressconfig. On update, oldIngressConfig is the object being
+// replaced, and only the rules it did not already have are checked, so entries accepted before a check existed
+// do not block unrelated changes.
+func (v *IngressConfigCustomValidator) validateIngressConfig(
+	oldIngressConfig, ingressconfig *kubebotblockergithubiov1beta1.IngressConfig,
+) (admission.Warnings, error) {
+	var (
+		allErrs   field.ErrorList
+		warnings  admission.Warnings
+		rulesPath = field.NewPath("spec", "blockedUserAgents")
+		// accepted holds the patterns of the object being updated, which are not checked again
+		accepted = make(map[string]bool)
+	)
+	if oldIngressConfig != nil {
+		for _, pattern := range oldIngressConfig.Spec.Patterns() {
+			accepted[pattern] = true
+		}
+	}
+
+	for i, rule := range ingressconfig.Spec.BlockedUserAgents {
+		if accepted[rule.Pattern] {
+			continue
+		}
+		patternPath := rulesPath.Index(i).Child("pattern")
+		if err := snippet.ValidatePattern(rule.Pattern); err != nil {
+			allErrs = append(allErrs, field.Invalid(patternPath, rule.Pattern, err.Error()))
+			continue
+		}
+		for _, warning := range snippet.PatternWarnings(rule.Pattern) {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", patternPath, warning))
+		}
+	}
+
+	if len(allErrs) == 0 {
+		_, redundant := snippet.Compile(ingressconfig.Spec.Patterns(), snippet.MaxRegexLength)
+		for _, entry := range redundant {
+			warnings = append(warnings, fmt.Sprintf("%s: already blocked by %q, left out of the generated configuration",
+				rulesPath.Index(entry.Index).Child("pattern"), entry.CoveredBy))
+		}
+	}
+
+	if v.MaxSnippetSize > 0 && len(allErrs) == 0 {
+		rendered := snippet.Build(ingressconfig.Spec.Patterns(), ingressconfig.Spec.Response.GetStatusCode())
+		// An object already over the budget may still be updated, as long as it does not grow
+		previousSize := 0
+		if oldIngressConfig != nil {
+			previousSize = len(snippet.Build(oldIngressConfig.Spec.Patterns(), oldIngressConfig.Spec.Response.GetStatusCode()))
+		}
+		if len(rendered) > v.MaxSnippetSize && len(rendered) > previousSize {
+			allErrs = append(allErrs, field.Forbidden(rulesPath, fmt.Sprintf(
+				"rendered NGINX configuration is %d bytes, which exceeds the limit of %d bytes",
+				len(rendered), v.MaxSnippetSize)))
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+
+	return warnings, apierrors.NewInvalid(
+		kubebotblockergithubiov1beta1.GroupVersion.WithKind("IngressConfig").GroupKind(),
+		ingressconfig.Name, allErrs)
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubebotblockergithubiov1beta1 "github.com/GustavoJST/kube-botblocker/api/v1beta1"
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+)
+
+func newIngressConfig(patterns ...string) *kubebotblockergithubiov1beta1.IngressConfig {
+	ingressConfig := &kubebotblockergithubiov1beta1.IngressConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kube-botblocker"},
+	}
+	for _, pattern := range patterns {
+		ingressConfig.Spec.BlockedUserAgents = append(ingressConfig.Spec.BlockedUserAgents,
+			kubebotblockergithubiov1beta1.UserAgentRule{Pattern: pattern})
+	}
+	return ingressConfig
+}
+
+func TestIngressConfigCustomValidator(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxSnippetSize int
+		ingressConfig  *kubebotblockergithubiov1beta1.IngressConfig
+		wantErr        bool
+		wantWarnings   int
+	}{
+		{
+			name:          "Valid patterns",
+			ingressConfig: newIngressConfig("GoogleBot", "AI2Bot", `^curl/[0-9.]+$`),
+		},
+		{
+			name:          "Invalid pattern",
+			ingressConfig: newIngressConfig("GoogleBot", `Bad"Bot`),
+			wantErr:       true,
+		},
+		{
+			name:          "ReDoS prone pattern",
+			ingressConfig: newIngressConfig("GoogleBot", "(a+)+b"),
+			wantWarnings:  1,
+		},
+		{
+			name:          "Redundant patterns",
+			ingressConfig: newIngressConfig("omgili", "omgilibot", "Meta-ExternalAgent", "meta-externalagent"),
+			wantWarnings:  2,
+		},
+		{
+			name:           "Rendered configuration within size budget",
+			maxSnippetSize: 1024,
+			ingressConfig:  newIngressConfig("GoogleBot"),
+		},
+		{
+			name:           "Rendered configuration over size budget",
+			maxSnippetSize: 256,
+			ingressConfig:  newIngressConfig(strings.Repeat("a", 200), strings.Repeat("b", 200)),
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &IngressConfigCustomValidator{MaxSnippetSize: tt.maxSnippetSize}
+
+			warnings, err := validator.ValidateCreate(context.Background(), tt.ingressConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCreate() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+			if err != nil && !apierrors.IsInvalid(err) {
+				t.Errorf("ValidateCreate() error - got: %v, expected an Invalid error", err)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("ValidateCreate() warnings - got: %v, expected %d warnings", warnings, tt.wantWarnings)
+			}
+
+			_, err = validator.ValidateUpdate(context.Background(), newIngressConfig(), tt.ingressConfig)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIngressConfigCustomValidatorRedundantWarnings(t *testing.T) {
+	validator := &IngressConfigCustomValidator{}
+	warnings, err := validator.ValidateCreate(context.Background(), newIngressConfig("GoogleBot", "GoogleBot"))
+	if err != nil {
+		t.Fatalf("ValidateCreate() error - got: %v, expected no error", err)
+	}
+	want := `spec.blockedUserAgents[1].pattern: already blocked by "GoogleBot", left out of the generated configuration`
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("ValidateCreate() warnings - got: %q, expected: %q", warnings, want)
+	}
+}
+
+func TestIngressConfigCustomValidatorUpdate(t *testing.T) {
+	// legacy is an IngressConfig stored before its pattern was rejected
+	legacy := newIngressConfig("GoogleBot", `Bad"Bot`)
+	withFinalizer := legacy.DeepCopy()
+	withFinalizer.Finalizers = []string{"kube-botblocker.github.io/cleanup"}
+
+	tests := []struct {
+		name           string
+		maxSnippetSize int
+		oldObj         *kubebotblockergithubiov1beta1.IngressConfig
+		newObj         *kubebotblockergithubiov1beta1.IngressConfig
+		wantErr        bool
+	}{
+		{
+			name:   "Metadata only update of a legacy object",
+			oldObj: legacy,
+			newObj: withFinalizer,
+		},
+		{
+			name:   "Valid rule added next to a legacy rule",
+			oldObj: legacy,
+			newObj: newIngressConfig("GoogleBot", `Bad"Bot`, "AI2Bot"),
+		},
+		{
+			name:    "Invalid rule added next to a legacy rule",
+			oldObj:  legacy,
+			newObj:  newIngressConfig("GoogleBot", `Bad"Bot`, `Worse"Bot`),
+			wantErr: true,
+		},
+		{
+			name:    "Rule changed to an invalid pattern",
+			oldObj:  newIngressConfig("GoogleBot"),
+			newObj:  newIngressConfig(`Google"Bot`),
+			wantErr: true,
+		},
+		{
+			name:           "Object over size budget shrinking",
+			maxSnippetSize: 256,
+			oldObj:         newIngressConfig(strings.Repeat("a", 200), strings.Repeat("b", 200), strings.Repeat("c", 200)),
+			newObj:         newIngressConfig(strings.Repeat("a", 200), strings.Repeat("b", 200)),
+		},
+		{
+			name:           "Object over size budget growing",
+			maxSnippetSize: 256,
+			oldObj:         newIngressConfig(strings.Repeat("a", 200), strings.Repeat("b", 200)),
+			newObj:         newIngressConfig(strings.Repeat("a", 200), strings.Repeat("b", 200), strings.Repeat("c", 200)),
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &IngressConfigCustomValidator{MaxSnippetSize: tt.maxSnippetSize}
+			_, err := validator.ValidateUpdate(context.Background(), tt.oldObj, tt.newObj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIngressConfigCustomValidatorDeleting(t *testing.T) {
+	ingressConfig := newIngressConfig(`Bad"Bot`)
+	now := metav1.Now()
+	ingressConfig.DeletionTimestamp = &now
+
+	validator := &IngressConfigCustomValidator{}
+	if _, err := validator.ValidateUpdate(context.Background(), ingressConfig, ingressConfig); err != nil {
+		t.Errorf("ValidateUpdate() error - got: %v, expected no error for an object being deleted", err)
+	}
+}
+
+func TestIngressConfigCustomValidatorDelete(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	var objs []client.Object
+	for i := range 12 {
+		objs = append(objs, &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("ingress-%02d", i),
+			Namespace:   "web",
+			Annotations: map[string]string{annotations.IngressConfigNameAnnotation: "test"},
+		}})
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithIndex(&networkingv1.Ingress{}, annotations.IngressConfigNameAnnotation, func(obj client.Object) []string {
+			if name := obj.GetAnnotations()[annotations.IngressConfigNameAnnotation]; name != "" {
+				return []string{name}
+			}
+			return nil
+		}).Build()
+
+	tests := []struct {
+		name      string
+		policy    kubebotblockergithubiov1beta1.DeletionPolicy
+		configure func(ingressConfig *kubebotblockergithubiov1beta1.IngressConfig)
+		wantErr   bool
+	}{
+		{
+			name: "Default policy",
+		},
+		{
+			name:   "Cascade policy",
+			policy: kubebotblockergithubiov1beta1.DeletionPolicyCascade,
+		},
+		{
+			name:   "Orphan policy",
+			policy: kubebotblockergithubiov1beta1.DeletionPolicyOrphan,
+		},
+		{
+			name:    "Block policy with referencing Ingresses",
+			policy:  kubebotblockergithubiov1beta1.DeletionPolicyBlock,
+			wantErr: true,
+		},
+		{
+			name:   "Block policy without referencing Ingresses",
+			policy: kubebotblockergithubiov1beta1.DeletionPolicyBlock,
+			configure: func(ingressConfig *kubebotblockergithubiov1beta1.IngressConfig) {
+				ingressConfig.Name = "unused"
+			},
+		},
+		{
+			name:   "Block policy outside of the operator namespace",
+			policy: kubebotblockergithubiov1beta1.DeletionPolicyBlock,
+			configure: func(ingressConfig *kubebotblockergithubiov1beta1.IngressConfig) {
+				ingressConfig.Namespace = "other"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingressConfig := newIngressConfig("GoogleBot")
+			ingressConfig.Spec.DeletionPolicy = tt.policy
+			if tt.configure != nil {
+				tt.configure(ingressConfig)
+			}
+			validator := &IngressConfigCustomValidator{Client: reader, OperatorNamespace: "kube-botblocker"}
+
+			_, err := validator.ValidateDelete(context.Background(), ingressConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateDelete() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if !apierrors.IsForbidden(err) {
+				t.Errorf("ValidateDelete() error - got: %v, expected a Forbidden error", err)
+			}
+			for _, want := range []string{"12 Ingresses", "web/ingress-00", "web/ingress-09", "and 2 more"} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateDelete() error - got: %v, expected it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -4,4 +4,11 @@ const (
 	IngressConfigNameAnnotation = "kube-botblocker.github.io/ingressConfigName"
 	IngressServerSnippet        = "nginx.ingress.kubernetes.io/server-snippet"
 	IngressConfigSpecHash       = "kube-botblocker.github.io/ingressConfigSpecHash"
+	IngressPaused               = "kube-botblocker.github.io/paused"
+	IngressLastError            = "kube-botblocker.github.io/lastError"
+	IngressSnippetBeforeRepair  = "kube-botblocker.github.io/snippetBeforeRepair"
+	IngressGeneratedSnippet     = "kube-botblocker.github.io/generatedSnippet"
+	IngressOriginalSnippetHash  = "kube-botblocker.github.io/originalSnippetHash"
+	IngressOrphaned             = "kube-botblocker.github.io/orphaned"
+	IngressRendererVersion      = "kube-botblocker.github.io/rendererVersion"
 )
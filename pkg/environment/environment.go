@@ -1,12 +1,91 @@
 package environment
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/caarlos0/env/v11"
+
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
+)
+
+// ReferenceValidationMode defines what the Ingress validating webhook does with invalid
+// IngressConfig references.
+type ReferenceValidationMode string
+
+const (
+	// ReferenceValidationReject denies the admission of Ingresses with invalid references.
+	ReferenceValidationReject ReferenceValidationMode = "Reject"
+	// ReferenceValidationWarn admits Ingresses with invalid references, returning a warning to the client.
+	ReferenceValidationWarn ReferenceValidationMode = "Warn"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, rejecting unknown modes.
+func (m *ReferenceValidationMode) UnmarshalText(text []byte) error {
+	switch mode := ReferenceValidationMode(text); mode {
+	case ReferenceValidationReject, ReferenceValidationWarn:
+		*m = mode
+		return nil
+	default:
+		return fmt.Errorf("invalid reference validation mode %q, must be one of %q or %q",
+			text, ReferenceValidationReject, ReferenceValidationWarn)
+	}
+}
+
+// SnippetMode defines which annotation of the Ingresses the operator writes its configuration to.
+type SnippetMode string
+
+const (
+	// SnippetModeServerSnippet writes the configuration to the server-snippet annotation.
+	SnippetModeServerSnippet SnippetMode = "ServerSnippet"
+	// SnippetModeAnnotation writes the configuration to a separate annotation and leaves the server-snippet
+	// annotation untouched, so a cooperating mechanism can copy it, for example a GitOps tool.
+	SnippetModeAnnotation SnippetMode = "Annotation"
 )
 
+// UnmarshalText implements encoding.TextUnmarshaler, rejecting unknown modes.
+func (m *SnippetMode) UnmarshalText(text []byte) error {
+	switch mode := SnippetMode(text); mode {
+	case SnippetModeServerSnippet, SnippetModeAnnotation:
+		*m = mode
+		return nil
+	default:
+		return fmt.Errorf("invalid snippet mode %q, must be one of %q or %q",
+			text, SnippetModeServerSnippet, SnippetModeAnnotation)
+	}
+}
+
 type OperatorEnv struct {
-	OperatorNamespace    string `env:"OPERATOR_NAMESPACE,required"`
-	CurrentNamespaceOnly bool   `env:"CURRENT_NAMESPACE_ONLY,required" envDefault:"false"`
+	OperatorNamespace          string                  `env:"OPERATOR_NAMESPACE,required"`
+	CurrentNamespaceOnly       bool                    `env:"CURRENT_NAMESPACE_ONLY,required" envDefault:"false"`
+	EnableWebhooks             bool                    `env:"ENABLE_WEBHOOKS" envDefault:"true"`
+	EnableConversionWebhook    bool                    `env:"ENABLE_CONVERSION_WEBHOOK" envDefault:"true"`
+	MaxSnippetSize             int                     `env:"MAX_SNIPPET_SIZE" envDefault:"32768"`
+	IngressReferenceValidation ReferenceValidationMode `env:"INGRESS_REFERENCE_VALIDATION" envDefault:"Reject"`
+	OperatorServiceAccount     string                  `env:"OPERATOR_SERVICE_ACCOUNT"`
+	DriftCheckInterval         time.Duration           `env:"DRIFT_CHECK_INTERVAL" envDefault:"10m"`
+	SnippetMode                SnippetMode             `env:"SNIPPET_MODE" envDefault:"ServerSnippet"`
+	FightDetectionThreshold    int                     `env:"FIGHT_DETECTION_THRESHOLD" envDefault:"5"`
+	FightDetectionWindow       time.Duration           `env:"FIGHT_DETECTION_WINDOW" envDefault:"10m"`
+	RerenderRate               float64                 `env:"RERENDER_RATE" envDefault:"5"`
+	RetainOrphanedConfig       bool                    `env:"RETAIN_ORPHANED_CONFIG" envDefault:"true"`
+}
+
+// SnippetAnnotation returns the Ingress annotation the operator writes its configuration to.
+func (e *OperatorEnv) SnippetAnnotation() string {
+	if e.SnippetMode == SnippetModeAnnotation {
+		return annotations.IngressGeneratedSnippet
+	}
+	return annotations.IngressServerSnippet
+}
+
+// OperatorUsername returns the username the operator authenticates as, or an empty
+// string if the operator service account is unknown.
+func (e *OperatorEnv) OperatorUsername() string {
+	if e.OperatorServiceAccount == "" {
+		return ""
+	}
+	return fmt.Sprintf("system:serviceaccount:%s:%s", e.OperatorNamespace, e.OperatorServiceAccount)
 }
 
 func GetOperatorEnv() (*OperatorEnv, error) {
@@ -4,25 +4,59 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/GustavoJST/kube-botblocker/pkg/annotations"
 )
 
 func TestGetOperatorEnv(t *testing.T) {
-	origOperatorNS, operatorNSExists := os.LookupEnv("OPERATOR_NAMESPACE")
-	origCurrentNSOnly, currentNSOnlyExists := os.LookupEnv("CURRENT_NAMESPACE_ONLY")
+	envVars := []string{
+		"OPERATOR_NAMESPACE",
+		"CURRENT_NAMESPACE_ONLY",
+		"ENABLE_WEBHOOKS",
+		"ENABLE_CONVERSION_WEBHOOK",
+		"MAX_SNIPPET_SIZE",
+		"INGRESS_REFERENCE_VALIDATION",
+		"OPERATOR_SERVICE_ACCOUNT",
+		"DRIFT_CHECK_INTERVAL",
+		"SNIPPET_MODE",
+		"FIGHT_DETECTION_THRESHOLD",
+		"FIGHT_DETECTION_WINDOW",
+		"RERENDER_RATE",
+		"RETAIN_ORPHANED_CONFIG",
+	}
 
-	t.Cleanup(func() {
-		if operatorNSExists {
-			os.Setenv("OPERATOR_NAMESPACE", origOperatorNS)
-		} else {
-			os.Unsetenv("OPERATOR_NAMESPACE")
-		}
+	for _, name := range envVars {
+		orig, exists := os.LookupEnv(name)
+		t.Cleanup(func() {
+			if exists {
+				os.Setenv(name, orig)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
 
-		if currentNSOnlyExists {
-			os.Setenv("CURRENT_NAMESPACE_ONLY", origCurrentNSOnly)
-		} else {
-			os.Unsetenv("CURRENT_NAMESPACE_ONLY")
+	// defaultEnv returns the OperatorEnv parsed with only OPERATOR_NAMESPACE set, changed by override
+	defaultEnv := func(override func(e *OperatorEnv)) *OperatorEnv {
+		e := &OperatorEnv{
+			OperatorNamespace:          "default",
+			EnableWebhooks:             true,
+			EnableConversionWebhook:    true,
+			MaxSnippetSize:             32768,
+			IngressReferenceValidation: ReferenceValidationReject,
+			DriftCheckInterval:         10 * time.Minute,
+			SnippetMode:                SnippetModeServerSnippet,
+			FightDetectionThreshold:    5,
+			FightDetectionWindow:       10 * time.Minute,
+			RerenderRate:               5,
+			RetainOrphanedConfig:       true,
+		}
+		if override != nil {
+			override(e)
 		}
-	})
+		return e
+	}
 
 	tests := []struct {
 		name    string
@@ -49,10 +83,7 @@ func TestGetOperatorEnv(t *testing.T) {
 			env: map[string]string{
 				"OPERATOR_NAMESPACE": "default",
 			},
-			want: &OperatorEnv{
-				OperatorNamespace:    "default",
-				CurrentNamespaceOnly: false,
-			},
+			want:    defaultEnv(nil),
 			wantErr: false,
 		},
 		{
@@ -61,17 +92,158 @@ func TestGetOperatorEnv(t *testing.T) {
 				"OPERATOR_NAMESPACE":     "default",
 				"CURRENT_NAMESPACE_ONLY": "true",
 			},
-			want: &OperatorEnv{
-				OperatorNamespace:    "default",
-				CurrentNamespaceOnly: true,
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.CurrentNamespaceOnly = true
+			}),
+			wantErr: false,
+		},
+		{
+			name: "ENABLE_WEBHOOKS set to false",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE": "default",
+				"ENABLE_WEBHOOKS":    "false",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.EnableWebhooks = false
+			}),
+			wantErr: false,
+		},
+		{
+			name: "ENABLE_CONVERSION_WEBHOOK set to false",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":        "default",
+				"ENABLE_WEBHOOKS":           "false",
+				"ENABLE_CONVERSION_WEBHOOK": "false",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.EnableWebhooks = false
+				e.EnableConversionWebhook = false
+			}),
+			wantErr: false,
+		},
+		{
+			name: "MAX_SNIPPET_SIZE set",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE": "default",
+				"MAX_SNIPPET_SIZE":   "4096",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.MaxSnippetSize = 4096
+			}),
+			wantErr: false,
+		},
+		{
+			name: "INGRESS_REFERENCE_VALIDATION set to Warn",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":           "default",
+				"INGRESS_REFERENCE_VALIDATION": "Warn",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.IngressReferenceValidation = ReferenceValidationWarn
+			}),
+			wantErr: false,
+		},
+		{
+			name: "OPERATOR_SERVICE_ACCOUNT set",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":       "default",
+				"OPERATOR_SERVICE_ACCOUNT": "kube-botblocker-operator",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.OperatorServiceAccount = "kube-botblocker-operator"
+			}),
+			wantErr: false,
+		},
+		{
+			name: "DRIFT_CHECK_INTERVAL set to 0",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":   "default",
+				"DRIFT_CHECK_INTERVAL": "0s",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.DriftCheckInterval = 0
+			}),
+			wantErr: false,
+		},
+		{
+			name: "SNIPPET_MODE set to Annotation",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE": "default",
+				"SNIPPET_MODE":       "Annotation",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.SnippetMode = SnippetModeAnnotation
+			}),
+			wantErr: false,
+		},
+		{
+			name: "FIGHT_DETECTION_THRESHOLD and FIGHT_DETECTION_WINDOW set",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":        "default",
+				"FIGHT_DETECTION_THRESHOLD": "3",
+				"FIGHT_DETECTION_WINDOW":    "1m",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.FightDetectionThreshold = 3
+				e.FightDetectionWindow = time.Minute
+			}),
+			wantErr: false,
+		},
+		{
+			name: "RERENDER_RATE set",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE": "default",
+				"RERENDER_RATE":      "0.5",
+			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.RerenderRate = 0.5
+			}),
+			wantErr: false,
+		},
+		{
+			name: "RETAIN_ORPHANED_CONFIG set to false",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":     "default",
+				"RETAIN_ORPHANED_CONFIG": "false",
 			},
+			want: defaultEnv(func(e *OperatorEnv) {
+				e.RetainOrphanedConfig = false
+			}),
 			wantErr: false,
 		},
+		{
+			name: "SNIPPET_MODE invalid",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE": "default",
+				"SNIPPET_MODE":       "ConfigurationSnippet",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "INGRESS_REFERENCE_VALIDATION invalid",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE":           "default",
+				"INGRESS_REFERENCE_VALIDATION": "Ignore",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "MAX_SNIPPET_SIZE not a number",
+			env: map[string]string{
+				"OPERATOR_NAMESPACE": "default",
+				"MAX_SNIPPET_SIZE":   "4k",
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			os.Unsetenv("OPERATOR_NAMESPACE")
-			os.Unsetenv("CURRENT_NAMESPACE_ONLY")
+			for _, name := range envVars {
+				os.Unsetenv(name)
+			}
 
 			for k, v := range tt.env {
 				t.Setenv(k, v)
@@ -87,3 +259,55 @@ func TestGetOperatorEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestOperatorUsername(t *testing.T) {
+	tests := []struct {
+		name string
+		env  OperatorEnv
+		want string
+	}{
+		{
+			name: "Service account set",
+			env:  OperatorEnv{OperatorNamespace: "kube-botblocker", OperatorServiceAccount: "operator"},
+			want: "system:serviceaccount:kube-botblocker:operator",
+		},
+		{
+			name: "Service account not set",
+			env:  OperatorEnv{OperatorNamespace: "kube-botblocker"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.env.OperatorUsername(); got != tt.want {
+				t.Errorf("OperatorUsername() - got: %q, expected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippetAnnotation(t *testing.T) {
+	tests := []struct {
+		name string
+		env  OperatorEnv
+		want string
+	}{
+		{
+			name: "ServerSnippet mode",
+			env:  OperatorEnv{SnippetMode: SnippetModeServerSnippet},
+			want: annotations.IngressServerSnippet,
+		},
+		{
+			name: "Annotation mode",
+			env:  OperatorEnv{SnippetMode: SnippetModeAnnotation},
+			want: annotations.IngressGeneratedSnippet,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.env.SnippetAnnotation(); got != tt.want {
+				t.Errorf("SnippetAnnotation() - got: %q, expected: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nginx parses NGINX configuration the way NGINX reads it, in the spirit of crossplane, so
+// configuration can be checked before ingress-nginx gets it.
+package nginx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Directive is a single NGINX directive, with the directives of its block if it has one.
+type Directive struct {
+	// Name of the directive.
+	Name string
+	// Args are the arguments of the directive, unquoted. The Lua code of a *_by_lua_block directive is kept
+	// as is as its last argument.
+	Args []string
+	// Line is the line of the configuration the directive starts at, starting from 1.
+	Line int
+	// Block holds the directives inside the braces of a block directive, nil for simple directives. It is
+	// empty for *_by_lua_block directives, whose block holds Lua code instead of directives.
+	Block []Directive
+}
+
+// IsBlock reports whether the directive is followed by a block instead of ending with ';'.
+func (d *Directive) IsBlock() bool {
+	return d.Block != nil
+}
+
+// Error is a problem found in NGINX configuration by Parse or Validate.
+type Error struct {
+	// Line of the configuration the error was found at, starting from 1.
+	Line int
+	// Message describes the error.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenSemicolon
+	tokenBlockStart
+	tokenBlockEnd
+	// tokenLuaBlock is the block of a *_by_lua_block directive, braces excluded.
+	tokenLuaBlock
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+// Parse parses conf as a list of directives, returning an *Error for the first syntax error found:
+// unterminated quotes, directives missing their ';', unbalanced braces and misplaced ';' or '{'. The blocks
+// of *_by_lua_block directives are Lua code, so they are only read up to their closing brace.
+func Parse(conf string) ([]Directive, error) {
+	tokens, err := lex(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	directives, err := p.parseBlock(false)
+	if err != nil {
+		return nil, err
+	}
+	return directives, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseBlock parses directives until the end of the configuration, or until the '}' closing the block
+// when nested is true.
+func (p *parser) parseBlock(nested bool) ([]Directive, error) {
+	directives := []Directive{}
+	for {
+		if p.pos == len(p.tokens) {
+			if nested {
+				return nil, &Error{Line: p.lastLine(), Message: `unexpected end of configuration, expecting "}"`}
+			}
+			return directives, nil
+		}
+
+		tok := p.tokens[p.pos]
+		p.pos++
+		switch tok.kind {
+		case tokenBlockEnd:
+			if !nested {
+				return nil, &Error{Line: tok.line, Message: `unexpected "}"`}
+			}
+			return directives, nil
+		case tokenSemicolon, tokenBlockStart:
+			return nil, &Error{Line: tok.line, Message: fmt.Sprintf("unexpected %q", tok.value)}
+		}
+
+		directive := Directive{Name: tok.value, Line: tok.line}
+		for {
+			if p.pos == len(p.tokens) {
+				return nil, &Error{Line: p.lastLine(), Message: fmt.Sprintf(
+					`unexpected end of configuration, expecting ";" or "{" after directive %q`, directive.Name)}
+			}
+			tok = p.tokens[p.pos]
+			p.pos++
+			if tok.kind == tokenWord {
+				directive.Args = append(directive.Args, tok.value)
+				continue
+			}
+			break
+		}
+
+		switch tok.kind {
+		case tokenBlockStart:
+			block, err := p.parseBlock(true)
+			if err != nil {
+				return nil, err
+			}
+			directive.Block = block
+		case tokenLuaBlock:
+			directive.Args = append(directive.Args, tok.value)
+			directive.Block = []Directive{}
+		case tokenBlockEnd:
+			return nil, &Error{Line: tok.line, Message: fmt.Sprintf(
+				`unexpected "}", expecting ";" after directive %q`, directive.Name)}
+		}
+		directives = append(directives, directive)
+	}
+}
+
+func (p *parser) lastLine() int {
+	if len(p.tokens) == 0 {
+		return 1
+	}
+	return p.tokens[len(p.tokens)-1].line
+}
+
+// lex splits conf into tokens as NGINX does: words are separated by whitespace, '#' starts a comment until
+// the end of the line, quotes group a word and '\' escapes the next character. As with lua-nginx-module, the
+// block of a *_by_lua_block directive is read as a single token.
+func lex(conf string) ([]token, error) {
+	var (
+		tokens []token
+		line   = 1
+		// luaBlock is whether the current directive is a *_by_lua_block one
+		luaBlock bool
+	)
+	for i := 0; i < len(conf); {
+		c := conf[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			end := strings.IndexByte(conf[i:], '\n')
+			if end < 0 {
+				end = len(conf) - i
+			}
+			i += end
+		case c == ';':
+			tokens = append(tokens, token{kind: tokenSemicolon, value: ";", line: line})
+			luaBlock = false
+			i++
+		case c == '{' && luaBlock:
+			start := line
+			value, next, lines, ok := lexLuaBlock(conf, i)
+			if !ok {
+				return nil, &Error{Line: start, Message: `unexpected end of configuration, expecting "}" closing Lua block`}
+			}
+			tokens = append(tokens, token{kind: tokenLuaBlock, value: value, line: start})
+			luaBlock = false
+			line += lines
+			i = next
+		case c == '{':
+			tokens = append(tokens, token{kind: tokenBlockStart, value: "{", line: line})
+			i++
+		case c == '}':
+			tokens = append(tokens, token{kind: tokenBlockEnd, value: "}", line: line})
+			luaBlock = false
+			i++
+		case c == '"' || c == '\'':
+			start := line
+			value, next, lines, ok := lexQuoted(conf, i)
+			if !ok {
+				return nil, &Error{Line: start, Message: fmt.Sprintf("unterminated %c quoted string", c)}
+			}
+			if next < len(conf) && strings.IndexByte(" \t\r\n;{)", conf[next]) < 0 {
+				return nil, &Error{Line: line + lines, Message: fmt.Sprintf("unexpected %q", string(conf[next]))}
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: value, line: start})
+			line += lines
+			i = next
+		default:
+			value, next, lines := lexWord(conf, i)
+			// Only the name of a directive starts a Lua block, not its arguments
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != tokenWord {
+				luaBlock = strings.HasSuffix(value, "_by_lua_block")
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: value, line: line})
+			line += lines
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// lexQuoted reads the quoted string starting at conf[start], returning it without the quotes, the index
+// after the closing quote and the number of new lines inside it.
+func lexQuoted(conf string, start int) (value string, next, lines int, ok bool) {
+	quote := conf[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(conf); i++ {
+		switch c := conf[i]; {
+		case c == '\\' && i+1 < len(conf):
+			i++
+			if conf[i] != quote && conf[i] != '\\' {
+				sb.WriteByte('\\')
+			}
+			if conf[i] == '\n' {
+				lines++
+			}
+			sb.WriteByte(conf[i])
+		case c == quote:
+			return sb.String(), i + 1, lines, true
+		default:
+			if c == '\n' {
+				lines++
+			}
+			sb.WriteByte(c)
+		}
+	}
+	return "", len(conf), lines, false
+}
+
+// lexWord reads the unquoted word starting at conf[start]. As in NGINX, only whitespace, ';' and '{' end a word,
+// and a '{' right after '$' is part of a variable name such as ${host}.
+func lexWord(conf string, start int) (value string, next, lines int) {
+	i := start
+	for i < len(conf) {
+		c := conf[i]
+		switch {
+		case c == '\\' && i+1 < len(conf):
+			if conf[i+1] == '\n' {
+				lines++
+			}
+			i += 2
+			continue
+		case c == '{' && i > start && conf[i-1] == '$':
+		case strings.IndexByte(" \t\r\n;{", c) >= 0:
+			return conf[start:i], i, lines
+		}
+		i++
+	}
+	return conf[start:i], i, lines
+}
+
+// lexLuaBlock reads the Lua code of the block starting at conf[start], returning it without the braces, the
+// index after the closing brace and the number of new lines inside it. Braces inside Lua strings and comments
+// don't count.
+func lexLuaBlock(conf string, start int) (value string, next, lines int, ok bool) {
+	depth := 0
+	for i := start; i < len(conf); {
+		c := conf[i]
+		switch {
+		case c == '\n':
+			lines++
+			i++
+		case c == '{':
+			depth++
+			i++
+		case c == '}':
+			depth--
+			i++
+			if depth == 0 {
+				return conf[start+1 : i-1], i, lines, true
+			}
+		case c == '"' || c == '\'':
+			i++
+			for i < len(conf) && conf[i] != c && conf[i] != '\n' {
+				if conf[i] == '\\' && i+1 < len(conf) {
+					if conf[i+1] == '\n' {
+						lines++
+					}
+					i++
+				}
+				i++
+			}
+			if i < len(conf) && conf[i] == c {
+				i++
+			}
+		case strings.HasPrefix(conf[i:], "--"):
+			i += 2
+			if end, ok := luaLongBracketEnd(conf, i); ok {
+				lines += strings.Count(conf[i:end], "\n")
+				i = end
+				continue
+			}
+			for i < len(conf) && conf[i] != '\n' {
+				i++
+			}
+		case c == '[':
+			if end, ok := luaLongBracketEnd(conf, i); ok {
+				lines += strings.Count(conf[i:end], "\n")
+				i = end
+				continue
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return "", len(conf), lines, false
+}
+
+// luaLongBracketEnd returns the index after the Lua long bracket, such as [[ ]] or [==[ ]==], starting at
+// conf[start], reporting whether there is one.
+func luaLongBracketEnd(conf string, start int) (int, bool) {
+	if start >= len(conf) || conf[start] != '[' {
+		return 0, false
+	}
+	level := 0
+	for start+1+level < len(conf) && conf[start+1+level] == '=' {
+		level++
+	}
+	if start+1+level >= len(conf) || conf[start+1+level] != '[' {
+		return 0, false
+	}
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(conf[start+2+level:], closing)
+	if end < 0 {
+		return len(conf), true
+	}
+	return start + 2 + level + end + len(closing), true
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     string
+		want     []Directive
+		wantLine int
+	}{
+		{
+			name: "Empty configuration",
+			want: []Directive{},
+		},
+		{
+			name: "Simple directives and comments",
+			conf: "# comment\nadd_header X-Frame-Options DENY; # trailing\nreturn 403;",
+			want: []Directive{
+				{Name: "add_header", Args: []string{"X-Frame-Options", "DENY"}, Line: 2},
+				{Name: "return", Args: []string{"403"}, Line: 3},
+			},
+		},
+		{
+			name: "Nested blocks",
+			conf: "location /api {\n  if ($http_user_agent ~* \"(bot|crawler)\") {\n    return 403;\n  }\n}",
+			want: []Directive{
+				{Name: "location", Args: []string{"/api"}, Line: 1, Block: []Directive{
+					{Name: "if", Args: []string{"($http_user_agent", "~*", "(bot|crawler)", ")"}, Line: 2, Block: []Directive{
+						{Name: "return", Args: []string{"403"}, Line: 3},
+					}},
+				}},
+			},
+		},
+		{
+			name: "Empty block",
+			conf: "location / {}",
+			want: []Directive{{Name: "location", Args: []string{"/"}, Line: 1, Block: []Directive{}}},
+		},
+		{
+			name: "Quoted strings with escapes and separators",
+			conf: `add_header X-Note "a; b { c } \"d\"" 'e # f';`,
+			want: []Directive{{Name: "add_header", Args: []string{"X-Note", `a; b { c } "d"`, "e # f"}, Line: 1}},
+		},
+		{
+			name: "Variables with braces",
+			conf: "set $target ${host}_suffix;",
+			want: []Directive{{Name: "set", Args: []string{"$target", "${host}_suffix"}, Line: 1}},
+		},
+		{
+			name: "Escaped separator in word",
+			conf: `rewrite ^/a\;b /c;`,
+			want: []Directive{{Name: "rewrite", Args: []string{`^/a\;b`, "/c"}, Line: 1}},
+		},
+		{
+			name: "Lua block",
+			conf: "set_by_lua_block $blocked {\n  return ngx.var.http_user_agent == \"}\" -- '{\n}\nreturn 403;",
+			want: []Directive{
+				{Name: "set_by_lua_block", Args: []string{"$blocked",
+					"\n  return ngx.var.http_user_agent == \"}\" -- '{\n"}, Line: 1, Block: []Directive{}},
+				{Name: "return", Args: []string{"403"}, Line: 4},
+			},
+		},
+		{
+			name: "Lua block with long brackets",
+			conf: "content_by_lua_block {\n  --[[ } ]]\n  ngx.say([==[\n}]==])\n}",
+			want: []Directive{{Name: "content_by_lua_block", Args: []string{"\n  --[[ } ]]\n  ngx.say([==[\n}]==])\n"},
+				Line: 1, Block: []Directive{}}},
+		},
+		{
+			name:     "Missing semicolon",
+			conf:     "return 403",
+			wantLine: 1,
+		},
+		{
+			name:     "Missing semicolon before closing brace",
+			conf:     "location / {\n  return 403\n}",
+			wantLine: 3,
+		},
+		{
+			name:     "Unclosed block",
+			conf:     "location / {\n  return 403;\n",
+			wantLine: 2,
+		},
+		{
+			name:     "Unexpected closing brace",
+			conf:     "return 403;\n}",
+			wantLine: 2,
+		},
+		{
+			name:     "Unexpected semicolon",
+			conf:     "return 403;\n;",
+			wantLine: 2,
+		},
+		{
+			name:     "Block without directive",
+			conf:     "{ return 403; }",
+			wantLine: 1,
+		},
+		{
+			name:     "Unterminated quoted string",
+			conf:     "return 403;\nadd_header X-Note \"open;",
+			wantLine: 2,
+		},
+		{
+			name:     "Unclosed Lua block",
+			conf:     "return 403;\naccess_by_lua_block {\n  ngx.exit(403)\n",
+			wantLine: 2,
+		},
+		{
+			name:     "Quoted string followed by text",
+			conf:     `add_header X-Note "a"b;`,
+			wantLine: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.conf)
+			if tt.wantLine != 0 {
+				var parseErr *Error
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("Parse() - got error: %v, expected an *Error", err)
+				}
+				if parseErr.Line != tt.wantLine {
+					t.Errorf("Parse() - got error at line %d (%v), expected line %d", parseErr.Line, err, tt.wantLine)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() - unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() - got: %+v, expected: %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// misplacedDirectives are the directives NGINX refuses inside a server block, with the reason why.
+var misplacedDirectives = map[string]string{
+	"load_module":      "is only allowed in the main context",
+	"user":             "is only allowed in the main context",
+	"worker_processes": "is only allowed in the main context",
+	"daemon":           "is only allowed in the main context",
+	"pid":              "is only allowed in the main context",
+	"env":              "is only allowed in the main context",
+	"events":           "is only allowed in the main context",
+	"http":             "is only allowed in the main context",
+	"stream":           "is only allowed in the main context",
+	"mail":             "is only allowed in the main context",
+	"server":           "is not allowed inside a server block",
+	"upstream":         "is only allowed in the http context",
+	"map":              "is only allowed in the http context",
+	"geo":              "is only allowed in the http context",
+	"split_clients":    "is only allowed in the http context",
+}
+
+// blockDirectives are the directives that must be followed by a block.
+var blockDirectives = map[string]bool{
+	"if":           true,
+	"location":     true,
+	"limit_except": true,
+}
+
+// simpleDirectives are common directives that must end with ';'.
+var simpleDirectives = map[string]bool{
+	"add_header":       true,
+	"allow":            true,
+	"break":            true,
+	"deny":             true,
+	"error_page":       true,
+	"more_set_headers": true,
+	"proxy_pass":       true,
+	"proxy_set_header": true,
+	"return":           true,
+	"rewrite":          true,
+	"set":              true,
+}
+
+// Validate parses conf, the content of a server-snippet, and checks its directives can be used inside an
+// NGINX server block. It returns an *Error for the first syntax error found, or for the first directive
+// that only works outside a server block. What the directives do, such as including files or running Lua
+// code, is left to the ingress-nginx configuration.
+func Validate(conf string) error {
+	directives, err := Parse(conf)
+	if err != nil {
+		return err
+	}
+	return validateBlock(directives)
+}
+
+func validateBlock(directives []Directive) error {
+	for _, directive := range directives {
+		if err := validateDirective(&directive); err != nil {
+			return err
+		}
+		if err := validateBlock(directive.Block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDirective(directive *Directive) error {
+	if reason, misplaced := misplacedDirectives[directive.Name]; misplaced {
+		return &Error{Line: directive.Line, Message: fmt.Sprintf("directive %q %s", directive.Name, reason)}
+	}
+
+	switch {
+	case blockDirectives[directive.Name] && !directive.IsBlock():
+		return &Error{Line: directive.Line, Message: fmt.Sprintf("directive %q has no opening \"{\"", directive.Name)}
+	case simpleDirectives[directive.Name] && directive.IsBlock():
+		return &Error{Line: directive.Line, Message: fmt.Sprintf(
+			"directive %q is not terminated by \";\"", directive.Name)}
+	}
+
+	if directive.Name == "if" {
+		if len(directive.Args) == 0 || !strings.HasPrefix(directive.Args[0], "(") ||
+			!strings.HasSuffix(directive.Args[len(directive.Args)-1], ")") {
+			return &Error{Line: directive.Line, Message: "invalid condition of directive \"if\", expecting \"(\" and \")\""}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"testing"
+
+	"github.com/GustavoJST/kube-botblocker/pkg/snippet"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		wantErr bool
+	}{
+		{
+			name: "Generated configuration",
+			conf: snippet.Build([]string{"GoogleBot", "AI2Bot", "python-requests/[0-9]+", `^curl/[0-9.]+$`}, 429),
+		},
+		{
+			name: "Generated configuration after user configuration",
+			conf: "location /health {\n  return 200;\n}\n\n" + snippet.Build([]string{"GoogleBot"}, 403),
+		},
+		{
+			name:    "Syntax error",
+			conf:    "return 403",
+			wantErr: true,
+		},
+		{
+			name:    "Directive only allowed in the main context",
+			conf:    "load_module modules/ngx_http_geoip_module.so;",
+			wantErr: true,
+		},
+		{
+			name: "Include",
+			conf: "location / {\n  include /etc/nginx/snippets/cache.conf;\n}",
+		},
+		{
+			name: "Lua",
+			conf: `set_by_lua $blocked "return 1";`,
+		},
+		{
+			name: "Lua block",
+			conf: "access_by_lua_block {\n  -- don't count { in comments\n  if ngx.var.arg_debug == \"}\" then\n    ngx.exit(403)\n  end\n}\n\n" +
+				snippet.Build([]string{"GoogleBot"}, 403),
+		},
+		{
+			name:    "Unterminated Lua block",
+			conf:    "content_by_lua_block {\n  ngx.say([[}]])\n",
+			wantErr: true,
+		},
+		{
+			name:    "Server block",
+			conf:    "server {\n  listen 8080;\n}",
+			wantErr: true,
+		},
+		{
+			name:    "If without block",
+			conf:    "if ($http_user_agent ~* bot) return 403;",
+			wantErr: true,
+		},
+		{
+			name:    "If without parentheses",
+			conf:    "if $http_user_agent ~* bot {\n  return 403;\n}",
+			wantErr: true,
+		},
+		{
+			name:    "Return with block",
+			conf:    "return 403 {\n}",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() - got error: %v, expected error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// regexMetacharacters are the characters that make a pattern more than a plain string to look for.
+const regexMetacharacters = `\.+*?()|[]{}^$`
+
+// Redundancy is a blocklist pattern left out of the rendered configuration, because every User-Agent it
+// matches is already matched by another pattern.
+type Redundancy struct {
+	// Index is the position of Pattern in the blocklist.
+	Index int
+	// Pattern is the pattern left out.
+	Pattern string
+	// CoveredBy is the pattern that already matches everything Pattern matches.
+	CoveredBy string
+}
+
+// Compile turns a blocklist into the alternatives of the User-Agent regular expression rendered by Build.
+// Since patterns are matched case insensitively anywhere in the User-Agent, duplicates differing only in case
+// and patterns containing another pattern (omgilibot contains omgili) are left out and returned as redundant.
+// The remaining plain strings are lowercased and factored by common prefix into trie-shaped alternatives,
+// each within maxLength when possible, followed by the other patterns in order.
+func Compile(patterns []string, maxLength int) (alternatives []string, redundant []Redundancy) {
+	var (
+		// literals holds the positions of the plain strings in patterns
+		literals []int
+		regexes  []string
+		// seen maps the normalised form of every kept pattern to the pattern itself
+		seen = make(map[string]string, len(patterns))
+	)
+	for i, pattern := range patterns {
+		key := normalise(pattern)
+		if first, ok := seen[key]; ok {
+			redundant = append(redundant, Redundancy{Index: i, Pattern: pattern, CoveredBy: first})
+			continue
+		}
+		seen[key] = pattern
+		if isLiteral(pattern) {
+			literals = append(literals, i)
+		} else {
+			regexes = append(regexes, pattern)
+		}
+	}
+
+	// Shorter literals go first, so the ones they are contained in find them already kept
+	slices.SortStableFunc(literals, func(a, b int) int { return len(patterns[a]) - len(patterns[b]) })
+	matchers := newLiteralMatchers(regexes)
+	kept := make(map[string]string, len(literals))
+	root := &trieNode{}
+	for _, i := range literals {
+		literal := patterns[i]
+		key := normalise(literal)
+		if coveredBy, ok := containedLiteral(key, kept); ok {
+			redundant = append(redundant, Redundancy{Index: i, Pattern: literal, CoveredBy: coveredBy})
+			continue
+		}
+		if coveredBy, ok := matchers.match(key); ok {
+			redundant = append(redundant, Redundancy{Index: i, Pattern: literal, CoveredBy: coveredBy})
+			continue
+		}
+		kept[key] = literal
+		root.insert(key)
+	}
+
+	alternatives = root.alternatives("", maxLength)
+	alternatives = append(alternatives, regexes...)
+	return alternatives, redundant
+}
+
+// isLiteral reports whether pattern only matches itself, so it can be compared and factored as a plain string.
+// Only ASCII is considered, as NGINX compares other characters case sensitively.
+func isLiteral(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] < ' ' || pattern[i] > '~' || strings.IndexByte(regexMetacharacters, pattern[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// normalise returns the form of pattern used to find duplicates. Patterns whose meaning can depend on case,
+// through escapes such as \S or inline flags, or with characters outside ASCII are kept as they are.
+func normalise(pattern string) string {
+	if strings.Contains(pattern, `\`) || strings.Contains(pattern, "(?") ||
+		strings.IndexFunc(pattern, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+		return pattern
+	}
+	return strings.ToLower(pattern)
+}
+
+// containedLiteral returns the kept literal found inside key, preferring the shortest one.
+func containedLiteral(key string, kept map[string]string) (string, bool) {
+	for length := 1; length < len(key); length++ {
+		for start := 0; start+length <= len(key); start++ {
+			if literal, ok := kept[key[start:start+length]]; ok {
+				return literal, true
+			}
+		}
+	}
+	return "", false
+}
+
+type literalMatcher struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// literalMatchers holds the regexes that can be checked against a literal. A regex matching somewhere in a
+// literal matches every User-Agent containing it, unless it depends on what surrounds the match or sets
+// flags that could make it case sensitive. Regexes starting with a plain string are indexed by it, so a
+// literal is only checked against the ones it can match.
+type literalMatchers struct {
+	byPrefix map[string][]literalMatcher
+	others   []literalMatcher
+}
+
+func newLiteralMatchers(regexes []string) *literalMatchers {
+	matchers := &literalMatchers{byPrefix: make(map[string][]literalMatcher)}
+	for _, pattern := range regexes {
+		if strings.Contains(pattern, "(?") {
+			continue
+		}
+		parsed, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil || hasAssertion(parsed) {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			continue
+		}
+		matcher := literalMatcher{pattern: pattern, re: re}
+		if prefix := literalPrefix(parsed.Simplify()); prefix != "" {
+			matchers.byPrefix[prefix] = append(matchers.byPrefix[prefix], matcher)
+		} else {
+			matchers.others = append(matchers.others, matcher)
+		}
+	}
+	return matchers
+}
+
+// match returns the first regex matching key, checking regexes indexed by a shorter prefix first.
+func (m *literalMatchers) match(key string) (string, bool) {
+	for length := 1; length <= len(key); length++ {
+		for start := 0; start+length <= len(key); start++ {
+			for _, matcher := range m.byPrefix[key[start:start+length]] {
+				if matcher.re.MatchString(key) {
+					return matcher.pattern, true
+				}
+			}
+		}
+	}
+	for _, matcher := range m.others {
+		if matcher.re.MatchString(key) {
+			return matcher.pattern, true
+		}
+	}
+	return "", false
+}
+
+// literalPrefix returns, lowercased, the plain string every match of re starts with, if any.
+func literalPrefix(re *syntax.Regexp) string {
+	if re.Op == syntax.OpConcat && len(re.Sub) > 0 {
+		re = re.Sub[0]
+	}
+	if re.Op != syntax.OpLiteral {
+		return ""
+	}
+	prefix := strings.ToLower(string(re.Rune))
+	if !isLiteral(prefix) {
+		return ""
+	}
+	return prefix
+}
+
+func hasAssertion(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasAssertion(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// trieNode is a node of the trie of the literals kept by Compile. As no kept literal contains another,
+// none is a prefix of another either, so only leaves end a literal.
+type trieNode struct {
+	children map[byte]*trieNode
+}
+
+func (n *trieNode) insert(literal string) {
+	for i := 0; i < len(literal); i++ {
+		if n.children == nil {
+			n.children = make(map[byte]*trieNode)
+		}
+		child, ok := n.children[literal[i]]
+		if !ok {
+			child = &trieNode{}
+			n.children[literal[i]] = child
+		}
+		n = child
+	}
+}
+
+func (n *trieNode) keys() []byte {
+	keys := make([]byte, 0, len(n.children))
+	for key := range n.children {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// regex returns the regular expression matching the suffixes of the literals below n.
+func (n *trieNode) regex() string {
+	keys := n.keys()
+	suffixes := make([]string, 0, len(keys))
+	for _, key := range keys {
+		suffixes = append(suffixes, string(key)+n.children[key].regex())
+	}
+	if len(suffixes) <= 1 {
+		return strings.Join(suffixes, "")
+	}
+	return "(?:" + strings.Join(suffixes, "|") + ")"
+}
+
+// alternatives returns one alternative per child of n, each prefixed with prefix. Children whose alternative
+// would be longer than maxLength are replaced by the alternatives of their own children.
+func (n *trieNode) alternatives(prefix string, maxLength int) []string {
+	var alternatives []string
+	for _, key := range n.keys() {
+		child := n.children[key]
+		childPrefix := prefix + string(key)
+		alternative := childPrefix + child.regex()
+		if len(alternative) <= maxLength || len(child.children) == 0 {
+			alternatives = append(alternatives, alternative)
+			continue
+		}
+		alternatives = append(alternatives, child.alternatives(childPrefix, maxLength)...)
+	}
+	return alternatives
+}
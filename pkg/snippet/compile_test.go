@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name          string
+		patterns      []string
+		maxLength     int
+		want          []string
+		wantRedundant []Redundancy
+	}{
+		{
+			name:     "Single pattern",
+			patterns: []string{"GoogleBot"},
+			want:     []string{"googlebot"},
+		},
+		{
+			name:     "Duplicates differing in case",
+			patterns: []string{"Meta-ExternalAgent", "meta-externalagent"},
+			want:     []string{"meta-externalagent"},
+			wantRedundant: []Redundancy{
+				{Index: 1, Pattern: "meta-externalagent", CoveredBy: "Meta-ExternalAgent"},
+			},
+		},
+		{
+			name:     "Pattern containing another",
+			patterns: []string{"omgilibot", "omgili", "Ai2Bot-Dolma", "AI2Bot"},
+			want:     []string{"ai2bot", "omgili"},
+			wantRedundant: []Redundancy{
+				{Index: 0, Pattern: "omgilibot", CoveredBy: "omgili"},
+				{Index: 2, Pattern: "Ai2Bot-Dolma", CoveredBy: "AI2Bot"},
+			},
+		},
+		{
+			name:     "Pattern containing another in the middle",
+			patterns: []string{"Bytespider", "spider"},
+			want:     []string{"spider"},
+			wantRedundant: []Redundancy{
+				{Index: 0, Pattern: "Bytespider", CoveredBy: "spider"},
+			},
+		},
+		{
+			name:     "Common prefixes",
+			patterns: []string{"Meta-ExternalAgent", "Meta-ExternalFetcher", "GPTBot", "GoogleOther", "Amazonbot"},
+			want:     []string{"amazonbot", "g(?:oogleother|ptbot)", "meta-external(?:agent|fetcher)"},
+		},
+		{
+			name:      "Alternatives split when longer than maxLength",
+			patterns:  []string{"crawlerone", "crawlertwo", "crawlerthree"},
+			maxLength: 20,
+			want:      []string{"crawlerone", "crawlert(?:hree|wo)"},
+		},
+		{
+			name:     "Regexes are kept in order after the literals",
+			patterns: []string{"python-requests/[0-9]+", "curl", "Go-http-client/1\\.1"},
+			want:     []string{"curl", "python-requests/[0-9]+", "Go-http-client/1\\.1"},
+		},
+		{
+			name:     "Regex duplicates differing in case",
+			patterns: []string{"Scrapy.*", "scrapy.*"},
+			want:     []string{"Scrapy.*"},
+			wantRedundant: []Redundancy{
+				{Index: 1, Pattern: "scrapy.*", CoveredBy: "Scrapy.*"},
+			},
+		},
+		{
+			name:     "Regexes depending on case are not normalised",
+			patterns: []string{`\S+bot`, `\s+bot`},
+			want:     []string{`\S+bot`, `\s+bot`},
+		},
+		{
+			name:     "Literal matched by a regex",
+			patterns: []string{"Bot[0-9]+", "Bot2000"},
+			want:     []string{"Bot[0-9]+"},
+			wantRedundant: []Redundancy{
+				{Index: 1, Pattern: "Bot2000", CoveredBy: "Bot[0-9]+"},
+			},
+		},
+		{
+			name:     "Literal matched by an anchored regex",
+			patterns: []string{"^Bot[0-9]+", "Bot2000"},
+			want:     []string{"bot2000", "^Bot[0-9]+"},
+		},
+		{
+			name:     "Literal matched by a case sensitive regex",
+			patterns: []string{"(?-i)bot[0-9]+", "Bot2000"},
+			want:     []string{"bot2000", "(?-i)bot[0-9]+"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxLength := tt.maxLength
+			if maxLength == 0 {
+				maxLength = MaxRegexLength
+			}
+			got, redundant := Compile(tt.patterns, maxLength)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compile() - got: %q, expected: %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(redundant, tt.wantRedundant) {
+				t.Errorf("Compile() - got redundant: %v, expected: %v", redundant, tt.wantRedundant)
+			}
+		})
+	}
+}
+
+func TestCompileMatchesSameUserAgents(t *testing.T) {
+	patterns := generatePatterns(1000)
+	alternatives, _ := Compile(patterns, MaxRegexLength)
+	compiled := regexp.MustCompile("(?i)(" + strings.Join(alternatives, "|") + ")")
+	original := regexp.MustCompile("(?i)(" + strings.Join(patterns, "|") + ")")
+
+	userAgents := []string{"Mozilla/5.0 (X11; Linux x86_64)", "curl/8.5.0", "Crawler0042Bot/1.0", "CRAWLER999X"}
+	for i := 0; i < 1200; i += 7 {
+		userAgents = append(userAgents, fmt.Sprintf("Mozilla/5.0 (compatible; %s)", generatePattern(i)))
+	}
+	for _, userAgent := range userAgents {
+		if got, want := compiled.MatchString(userAgent), original.MatchString(userAgent); got != want {
+			t.Errorf("Compile() - %q matched: %t, expected: %t", userAgent, got, want)
+		}
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	patterns := generatePatterns(10000)
+	b.ResetTimer()
+	for range b.N {
+		Compile(patterns, MaxRegexLength)
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	patterns := generatePatterns(10000)
+	b.ResetTimer()
+	for range b.N {
+		Build(patterns, 403)
+	}
+}
+
+// generatePatterns returns n patterns sharing prefixes, with some of them redundant.
+func generatePatterns(n int) []string {
+	patterns := make([]string, 0, n)
+	for i := range n {
+		patterns = append(patterns, generatePattern(i))
+	}
+	return patterns
+}
+
+func generatePattern(i int) string {
+	switch i % 5 {
+	case 0:
+		return fmt.Sprintf("Crawler%04dBot", i)
+	case 1:
+		return fmt.Sprintf("crawler%04dbot", i-1)
+	case 2:
+		return fmt.Sprintf("Spider-%d", i)
+	case 3:
+		return fmt.Sprintf("Fetcher%d/[0-9]+", i)
+	default:
+		return fmt.Sprintf("Agent%x", i*7919)
+	}
+}
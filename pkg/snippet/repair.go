@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+var (
+	startMarkerLine = strings.TrimSuffix(StartMarker, "\n")
+
+	// Lines of the rule rendered by Build
+	generatedIfLine     = regexp.MustCompile(`^if \(\$http_user_agent ~\* "\(.*\)"\) \{$`)
+	generatedReturnLine = regexp.MustCompile(`^return \d+;$`)
+)
+
+// Apply replaces the operator block inside currentConf with updatedConf like Update. If the markers in
+// currentConf are corrupted and policy allows it, the block is repaired instead of returning an error.
+// repaired reports whether currentConf had to be repaired.
+func Apply(currentConf, updatedConf string, policy v1beta1.MarkerRepairPolicy) (result string, repaired bool, err error) {
+	result, err = Update(currentConf, updatedConf)
+	if err == nil || policy == "" || policy == v1beta1.MarkerRepairFail {
+		return result, false, err
+	}
+
+	result, err = Repair(currentConf, updatedConf, policy)
+	if err != nil {
+		return "", false, err
+	}
+	return result, true, nil
+}
+
+// Repair recovers the operator block of a conf whose markers were edited or removed, keeping all user content,
+// and replaces it with updatedConf. An empty updatedConf removes the block.
+//
+// With RemoveOrphanMarkers, the first complete block is kept and every other marker line is removed.
+// With RebuildManagedBlock, every marker line and every line generated by Build is removed, and updatedConf is
+// written where the first of them was.
+func Repair(conf, updatedConf string, policy v1beta1.MarkerRepairPolicy) (string, error) {
+	var rebuild bool
+	switch policy {
+	case v1beta1.MarkerRepairRemoveOrphanMarkers:
+	case v1beta1.MarkerRepairRebuildManagedBlock:
+		rebuild = true
+	default:
+		return "", fmt.Errorf("unsupported marker repair policy %q", policy)
+	}
+
+	lines := strings.Split(conf, "\n")
+	keepStart, keepEnd := -1, -1
+	if !rebuild {
+		keepStart, keepEnd = firstCompleteBlock(lines)
+	}
+
+	var out []string
+	insertAt := -1
+	prevRemoved := false
+	for i := 0; i < len(lines); i++ {
+		if i >= keepStart && i <= keepEnd {
+			out = append(out, lines[i])
+			continue
+		}
+
+		trimmed := strings.TrimSpace(lines[i])
+		removed := 0
+		switch {
+		case isMarkerLine(trimmed):
+			removed = 1
+		case rebuild && trimmed == headerLine:
+			removed = 1
+		case rebuild:
+			// User rules looking like the generated one are only removed next to a marker or header line
+			if n := generatedRuleLines(lines[i:]); n > 0 && (prevRemoved || followedByMarker(lines[i+n:])) {
+				removed = n
+			}
+		}
+		prevRemoved = removed > 0
+		if removed == 0 {
+			out = append(out, lines[i])
+			continue
+		}
+
+		if insertAt < 0 {
+			insertAt = len(out)
+		}
+		i += removed - 1
+	}
+
+	if rebuild && insertAt >= 0 && updatedConf != "" {
+		out = append(out[:insertAt], append([]string{updatedConf}, out[insertAt:]...)...)
+		return strings.TrimSpace(strings.Join(out, "\n")), nil
+	}
+
+	return Update(strings.TrimSpace(strings.Join(out, "\n")), updatedConf)
+}
+
+func isMarkerLine(line string) bool {
+	return line == startMarkerLine || line == EndMarker
+}
+
+func followedByMarker(lines []string) bool {
+	return len(lines) > 0 && isMarkerLine(strings.TrimSpace(lines[0]))
+}
+
+// firstCompleteBlock returns the indexes of the start and end marker lines of the first block
+// with no other marker in between, or -1 if there is none.
+func firstCompleteBlock(lines []string) (start, end int) {
+	start = -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case startMarkerLine:
+			start = i
+		case EndMarker:
+			if start >= 0 {
+				return start, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// generatedRuleLines returns the number of lines of the rule rendered by Build found at the start of lines,
+// or 0 if lines doesn't start with such a rule.
+func generatedRuleLines(lines []string) int {
+	if len(lines) < 3 ||
+		!generatedIfLine.MatchString(strings.TrimSpace(lines[0])) ||
+		!generatedReturnLine.MatchString(strings.TrimSpace(lines[1])) ||
+		strings.TrimSpace(lines[2]) != "}" {
+		return 0
+	}
+	return 3
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+func TestRepair(t *testing.T) {
+	block := Build([]string{"GoogleBot"}, 403)
+	newBlock := Build([]string{"AI2Bot"}, 403)
+	body := strings.TrimSuffix(strings.TrimPrefix(block, StartMarker), EndMarker)
+	existing := "location /foo {\n  return 200;\n}"
+	userRule := `if ($http_user_agent ~* "(curl|wget)") {` + "\n  return 403;\n}"
+
+	tests := []struct {
+		name    string
+		policy  v1beta1.MarkerRepairPolicy
+		current string
+		updated string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Remove orphan markers - stray start marker before the block",
+			policy:  v1beta1.MarkerRepairRemoveOrphanMarkers,
+			current: StartMarker + existing + "\n\n" + block,
+			updated: newBlock,
+			want:    existing + "\n\n" + newBlock,
+		},
+		{
+			name:    "Remove orphan markers - stray end marker after the block",
+			policy:  v1beta1.MarkerRepairRemoveOrphanMarkers,
+			current: existing + "\n\n" + block + "\n" + EndMarker,
+			updated: newBlock,
+			want:    existing + "\n\n" + newBlock,
+		},
+		{
+			name:    "Remove orphan markers - missing end marker",
+			policy:  v1beta1.MarkerRepairRemoveOrphanMarkers,
+			current: existing + "\n\n" + StartMarker,
+			updated: newBlock,
+			want:    existing + "\n\n" + newBlock,
+		},
+		{
+			name:    "Remove orphan markers - removal",
+			policy:  v1beta1.MarkerRepairRemoveOrphanMarkers,
+			current: existing + "\n\n" + block + "\n" + EndMarker,
+			updated: "",
+			want:    existing,
+		},
+		{
+			name:    "Rebuild managed block - missing end marker",
+			policy:  v1beta1.MarkerRepairRebuildManagedBlock,
+			current: existing + "\n\n" + StartMarker + body,
+			updated: newBlock,
+			want:    existing + "\n\n" + newBlock,
+		},
+		{
+			name:    "Rebuild managed block - missing start marker keeps the block position",
+			policy:  v1beta1.MarkerRepairRebuildManagedBlock,
+			current: body + EndMarker + "\n" + existing,
+			updated: newBlock,
+			want:    newBlock + "\n" + existing,
+		},
+		{
+			name:    "Rebuild managed block - duplicated block",
+			policy:  v1beta1.MarkerRepairRebuildManagedBlock,
+			current: block + "\n\n" + existing + "\n\n" + block,
+			updated: newBlock,
+			want:    newBlock + "\n\n" + existing,
+		},
+		{
+			name:    "Rebuild managed block - keeps user rules away from markers",
+			policy:  v1beta1.MarkerRepairRebuildManagedBlock,
+			current: userRule + "\n\n" + StartMarker + body,
+			updated: newBlock,
+			want:    userRule + "\n\n" + newBlock,
+		},
+		{
+			name:    "Rebuild managed block - removal",
+			policy:  v1beta1.MarkerRepairRebuildManagedBlock,
+			current: existing + "\n\n" + StartMarker + body,
+			updated: "",
+			want:    existing,
+		},
+		{
+			name:    "Unsupported policy",
+			policy:  v1beta1.MarkerRepairFail,
+			current: existing + "\n\n" + StartMarker,
+			updated: newBlock,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Repair(tt.current, tt.updated, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Repair() error - got: %v, expected: %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Repair() value - got: %q, expected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	block := Build([]string{"GoogleBot"}, 403)
+	broken := "location /foo {\n  return 200;\n}\n\n" + StartMarker
+
+	tests := []struct {
+		name         string
+		policy       v1beta1.MarkerRepairPolicy
+		current      string
+		wantRepaired bool
+		wantErr      bool
+	}{
+		{name: "Valid snippet", policy: v1beta1.MarkerRepairRebuildManagedBlock, current: block},
+		{name: "Default policy fails", policy: "", current: broken, wantErr: true},
+		{name: "Fail policy fails", policy: v1beta1.MarkerRepairFail, current: broken, wantErr: true},
+		{name: "Repairing policy", policy: v1beta1.MarkerRepairRebuildManagedBlock, current: broken, wantRepaired: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, repaired, err := Apply(tt.current, block, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error - got: %v, expected: %v", err, tt.wantErr)
+			}
+			if repaired != tt.wantRepaired {
+				t.Errorf("Apply() repaired - got: %v, expected: %v", repaired, tt.wantRepaired)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GustavoJST/kube-botblocker/api/v1beta1"
+)
+
+// RendererVersion is the version of the configuration rendered by Build. It must be increased whenever Build
+// renders different configuration for the same input, so Ingresses configured by earlier releases are rendered
+// again. Ingresses configured before it was recorded on them were rendered with version 1.
+//
+// Version 2 splits long blocklists across several if blocks.
+// Version 3 leaves out redundant patterns and factors the others by common prefix, see Compile.
+const RendererVersion int32 = 3
+
+// MaxRegexLength is the maximum length of the User-Agent regular expression of a single if block. Longer
+// blocklists are split across several if blocks, keeping each line of the configuration short.
+const MaxRegexLength = 2048
+
+var (
+	StartMarker = fmt.Sprintf("# %s operator: Configuration start\n", v1beta1.GroupVersion.Group)
+	EndMarker   = fmt.Sprintf("# %s operator: Configuration end", v1beta1.GroupVersion.Group)
+
+	headerLine = "# Configuration added by kube-botblocker operator. Do not edit any of this manually"
+
+	blockPattern = regexp.MustCompile("(?sm)^" + regexp.QuoteMeta(StartMarker) + ".*?" + regexp.QuoteMeta(EndMarker) + "$")
+)
+
+// Build renders the NGINX configuration block that blocks the given User-Agent patterns,
+// wrapped in the operator markers. Patterns are compiled with Compile, then split across as
+// many if blocks as needed to keep each regular expression within MaxRegexLength.
+func Build(userAgents []string, statusCode int32) string {
+	var sb strings.Builder
+
+	alternatives, _ := Compile(userAgents, MaxRegexLength)
+	sb.WriteString(StartMarker)
+	sb.WriteString(headerLine + "\n")
+	for _, group := range splitPatterns(alternatives, MaxRegexLength) {
+		sb.WriteString(fmt.Sprintf(`if ($http_user_agent ~* "(%s)") {`, strings.Join(group, "|")))
+		sb.WriteString(fmt.Sprintf("\n  return %d;\n", statusCode))
+		sb.WriteString("}\n")
+	}
+	sb.WriteString(EndMarker)
+
+	return sb.String()
+}
+
+// splitPatterns groups patterns in order so the alternation of each group is at most maxLength long.
+// A pattern longer than maxLength gets a group of its own. There is always at least one group.
+func splitPatterns(patterns []string, maxLength int) [][]string {
+	groups := [][]string{nil}
+	length := 0
+	for _, pattern := range patterns {
+		last := len(groups) - 1
+		if len(groups[last]) > 0 && length+1+len(pattern) > maxLength {
+			groups = append(groups, nil)
+			last++
+			length = 0
+		}
+		if len(groups[last]) > 0 {
+			length++
+		}
+		groups[last] = append(groups[last], pattern)
+		length += len(pattern)
+	}
+	return groups
+}
+
+// Extract returns the operator block contained in conf, or an empty string if there is none.
+// ok is false when the markers in conf are mismatched or duplicated, so the block can't be located.
+func Extract(conf string) (block string, ok bool) {
+	startMarkerCount := strings.Count(conf, StartMarker)
+	endMarkerCount := strings.Count(conf, EndMarker)
+
+	switch {
+	case startMarkerCount == 0 && endMarkerCount == 0:
+		return "", true
+	case startMarkerCount != 1 || endMarkerCount != 1:
+		return "", false
+	}
+
+	block = blockPattern.FindString(conf)
+	return block, block != ""
+}
+
+// HasMarkers reports whether conf contains any of the operator markers, even if they are mismatched.
+func HasMarkers(conf string) bool {
+	return strings.Contains(conf, startMarkerLine) || strings.Contains(conf, EndMarker)
+}
+
+// Update replaces the operator block inside currentConf with updatedConf, appending it if
+// currentConf has no block yet. An empty updatedConf removes the block.
+func Update(currentConf, updatedConf string) (string, error) {
+	startMarkerCount := strings.Count(currentConf, StartMarker)
+	endMarkerCount := strings.Count(currentConf, EndMarker)
+
+	if startMarkerCount != endMarkerCount || startMarkerCount > 1 && endMarkerCount > 1 {
+		return "", fmt.Errorf(
+			"mismatched or wrong number of start and end markers for kube-botblocker config. "+
+				"Expected 1 start and end markers, got %d start and %d end markers. Manual action required",
+			startMarkerCount, endMarkerCount,
+		)
+	}
+
+	if updatedConf == "" {
+		result := blockPattern.ReplaceAllLiteralString(currentConf, "")
+		result = strings.TrimSpace(result)
+		return result, nil
+	}
+
+	// Add updatedConf if currentConf is empty or doesn't have a valid kube-botblocker config
+	// with start and end markers
+	if !blockPattern.MatchString(currentConf) {
+		if currentConf == "" {
+			return updatedConf, nil
+		}
+		return currentConf + "\n\n" + updatedConf, nil
+	}
+
+	return blockPattern.ReplaceAllLiteralString(currentConf, updatedConf), nil
+}
+
+// Hash returns the hash of conf used by Restore to check it gets conf back exactly.
+func Hash(conf string) string {
+	sum := sha256.Sum256([]byte(conf))
+	return hex.EncodeToString(sum[:])
+}
+
+// Restore removes the operator block from conf, undoing exactly what Update did when it added the block,
+// so the content around it is kept byte for byte. exact is false when the result doesn't match originalHash,
+// the Hash of conf before the block was added, meaning that content was changed since.
+func Restore(conf, originalHash string) (result string, exact bool) {
+	block, ok := Extract(conf)
+	if !ok {
+		return "", false
+	}
+
+	candidates := []string{conf}
+	if block != "" {
+		candidates = []string{
+			strings.Replace(conf, "\n\n"+block, "", 1),
+			strings.Replace(conf, block, "", 1),
+		}
+	}
+	for _, candidate := range candidates {
+		if Hash(candidate) == originalHash {
+			return candidate, true
+		}
+	}
+	return "", false
+}
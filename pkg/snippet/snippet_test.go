@@ -0,0 +1,304 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	want := StartMarker +
+		"# Configuration added by kube-botblocker operator. Do not edit any of this manually\n" +
+		`if ($http_user_agent ~* "(a(?:i2bot|mazonbot)|googlebot|bot[0-9]+)") {` + "\n" +
+		"  return 429;\n" +
+		"}\n" +
+		EndMarker
+
+	if got := Build([]string{"GoogleBot", "AI2Bot", "bot[0-9]+", "Amazonbot", "Ai2Bot-Dolma"}, 429); got != want {
+		t.Errorf("Build() - got: %q, expected: %q", got, want)
+	}
+}
+
+func TestBuildSplitsLongBlocklists(t *testing.T) {
+	var patterns []string
+	for i := range 500 {
+		patterns = append(patterns, fmt.Sprintf("%03dCrawler", i))
+	}
+
+	got := Build(patterns, 403)
+	ifBlocks := strings.Count(got, "if ($http_user_agent")
+	if ifBlocks < 2 {
+		t.Fatalf("Build() - got %d if blocks, expected the blocklist to be split", ifBlocks)
+	}
+
+	var regexes []*regexp.Regexp
+	for _, line := range strings.Split(got, "\n") {
+		regex, ok := strings.CutPrefix(line, `if ($http_user_agent ~* "`)
+		if !ok {
+			continue
+		}
+		regex = strings.TrimSuffix(regex, `") {`)
+		if len(regex) > MaxRegexLength+2 {
+			t.Errorf("Build() - got a regular expression of %d bytes, expected at most %d", len(regex)-2, MaxRegexLength)
+		}
+		regexes = append(regexes, regexp.MustCompile("(?i)"+regex))
+	}
+	matches := func(userAgent string) bool {
+		return slices.ContainsFunc(regexes, func(re *regexp.Regexp) bool { return re.MatchString(userAgent) })
+	}
+	for _, pattern := range patterns {
+		if !matches("Mozilla/5.0 (compatible; " + pattern + ")") {
+			t.Errorf("Build() - expected %q to be blocked", pattern)
+		}
+	}
+	if matches("Mozilla/5.0 (compatible; 500Crawler)") {
+		t.Errorf("Build() - expected a User-Agent not in the blocklist not to be blocked")
+	}
+}
+
+func TestSplitPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     [][]string
+	}{
+		{
+			name: "No patterns",
+			want: [][]string{nil},
+		},
+		{
+			name:     "Within limit",
+			patterns: []string{"ab", "cd"},
+			want:     [][]string{{"ab", "cd"}},
+		},
+		{
+			name:     "Split at limit",
+			patterns: []string{"ab", "cd", "ef"},
+			want:     [][]string{{"ab", "cd"}, {"ef"}},
+		},
+		{
+			name:     "Pattern longer than limit",
+			patterns: []string{"ab", "abcdefgh", "cd"},
+			want:     [][]string{{"ab"}, {"abcdefgh"}, {"cd"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitPatterns(tt.patterns, 5)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitPatterns() - got: %q, expected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	block := Build([]string{"GoogleBot"}, 403)
+	newBlock := Build([]string{"AI2Bot"}, 403)
+	existing := "location /foo {\n  return 200;\n}"
+
+	tests := []struct {
+		name    string
+		current string
+		updated string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Empty snippet",
+			current: "",
+			updated: block,
+			want:    block,
+		},
+		{
+			name:    "Append to existing snippet",
+			current: existing,
+			updated: block,
+			want:    existing + "\n\n" + block,
+		},
+		{
+			name:    "Replace existing block",
+			current: existing + "\n\n" + block,
+			updated: newBlock,
+			want:    existing + "\n\n" + newBlock,
+		},
+		{
+			name:    "Remove block",
+			current: existing + "\n\n" + block,
+			updated: "",
+			want:    existing,
+		},
+		{
+			name:    "Remove only block",
+			current: block,
+			updated: "",
+			want:    "",
+		},
+		{
+			name:    "Missing end marker",
+			current: existing + "\n\n" + StartMarker,
+			updated: block,
+			wantErr: true,
+		},
+		{
+			name:    "Duplicated block",
+			current: block + "\n\n" + block,
+			updated: newBlock,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Update(tt.current, tt.updated)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Update() error - got: %v, expected: %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Update() value - got: %q, expected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	block := Build([]string{"GoogleBot"}, 403)
+	existing := "location /foo {\n  return 200;\n}"
+
+	tests := []struct {
+		name      string
+		conf      string
+		wantBlock string
+		wantOk    bool
+	}{
+		{name: "Empty snippet", conf: "", wantBlock: "", wantOk: true},
+		{name: "No block", conf: existing, wantBlock: "", wantOk: true},
+		{name: "Only block", conf: block, wantBlock: block, wantOk: true},
+		{name: "Block after existing snippet", conf: existing + "\n\n" + block, wantBlock: block, wantOk: true},
+		{name: "Block before existing snippet", conf: block + "\n" + existing, wantBlock: block, wantOk: true},
+		{name: "Missing end marker", conf: existing + "\n\n" + StartMarker, wantOk: false},
+		{name: "End marker before start marker", conf: EndMarker + "\n" + StartMarker, wantOk: false},
+		{name: "Duplicated block", conf: block + "\n\n" + block, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBlock, gotOk := Extract(tt.conf)
+			if gotOk != tt.wantOk {
+				t.Errorf("Extract() ok - got: %v, expected: %v", gotOk, tt.wantOk)
+				return
+			}
+			if gotBlock != tt.wantBlock {
+				t.Errorf("Extract() block - got: %q, expected: %q", gotBlock, tt.wantBlock)
+			}
+		})
+	}
+}
+
+func TestHasMarkers(t *testing.T) {
+	block := Build([]string{"GoogleBot"}, 403)
+	existing := "location /foo {\n  return 200;\n}"
+
+	tests := []struct {
+		name string
+		conf string
+		want bool
+	}{
+		{name: "Empty snippet", conf: "", want: false},
+		{name: "Only user configuration", conf: existing, want: false},
+		{name: "Complete block", conf: existing + "\n\n" + block, want: true},
+		{name: "Only start marker", conf: existing + "\n\n" + strings.TrimSuffix(StartMarker, "\n"), want: true},
+		{name: "Only end marker", conf: EndMarker + "\n" + existing, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasMarkers(tt.conf); got != tt.want {
+				t.Errorf("HasMarkers() - got: %v, expected: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	block := Build([]string{"GoogleBot"}, 403)
+	newBlock := Build([]string{"AI2Bot"}, 403)
+	existing := "\n  location /foo {\n    return 200;\n  }\n"
+
+	tests := []struct {
+		name      string
+		original  string
+		conf      string
+		want      string
+		wantExact bool
+	}{
+		{
+			name:      "Appended block",
+			original:  existing,
+			conf:      existing + "\n\n" + block,
+			want:      existing,
+			wantExact: true,
+		},
+		{
+			name:      "Only block",
+			original:  "",
+			conf:      block,
+			want:      "",
+			wantExact: true,
+		},
+		{
+			name:      "Block replaced after a spec change",
+			original:  existing,
+			conf:      existing + "\n\n" + newBlock,
+			want:      existing,
+			wantExact: true,
+		},
+		{
+			name:      "Block already removed",
+			original:  existing,
+			conf:      existing,
+			want:      existing,
+			wantExact: true,
+		},
+		{
+			name:      "Content changed since the block was added",
+			original:  existing,
+			conf:      existing + "\n\n" + block + "\nlocation /bar {}",
+			wantExact: false,
+		},
+		{
+			name:      "Broken markers",
+			original:  existing,
+			conf:      existing + "\n\n" + StartMarker,
+			wantExact: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, exact := Restore(tt.conf, Hash(tt.original))
+			if exact != tt.wantExact {
+				t.Errorf("Restore() exact - got: %v, expected: %v", exact, tt.wantExact)
+			}
+			if got != tt.want {
+				t.Errorf("Restore() value - got: %q, expected: %q", got, tt.want)
+			}
+		})
+	}
+}
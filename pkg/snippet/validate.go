@@ -0,0 +1,105 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// ValidatePattern checks that a User-Agent pattern can be rendered safely by Build.
+// Patterns are placed inside a double quoted NGINX string and joined with the other
+// patterns of the IngressConfig in a single case insensitive regex, so anything that
+// could break out of the string, unbalance the enclosing group or match every
+// request is rejected. Only the regex syntax shared by RE2 and PCRE is accepted.
+func ValidatePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("must not be empty or only whitespace")
+	}
+	if strings.TrimSpace(pattern) != pattern {
+		return errors.New("must not start or end with whitespace")
+	}
+	if i := strings.IndexFunc(pattern, unicode.IsControl); i >= 0 {
+		return fmt.Errorf("must not contain control characters, found %q", pattern[i])
+	}
+	if strings.Contains(pattern, `"`) {
+		return errors.New(`must not contain '"', which would terminate the NGINX string`)
+	}
+	if strings.ContainsAny(pattern, "{}") {
+		return errors.New("must not contain '{' or '}', which NGINX reads as block delimiters")
+	}
+	if trailingBackslashes(pattern)%2 == 1 {
+		return errors.New(`must not end with an unescaped '\'`)
+	}
+
+	if _, err := syntax.Parse(pattern, syntax.Perl); err != nil {
+		return fmt.Errorf("invalid or unsupported regular expression: %w", err)
+	}
+	if regexp.MustCompile("(?i)" + pattern).MatchString("") {
+		return errors.New("must not match an empty User-Agent, as it would block every request")
+	}
+
+	return nil
+}
+
+// PatternWarnings returns non-fatal findings about a pattern that passed ValidatePattern,
+// such as constructs prone to catastrophic backtracking (ReDoS) in PCRE.
+func PatternWarnings(pattern string) []string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	if hasNestedRepetition(re, false) {
+		warnings = append(warnings,
+			"contains a repeated group with an unbounded repetition inside (e.g. (a+)+), "+
+				"which can cause catastrophic backtracking in NGINX")
+	}
+	return warnings
+}
+
+func hasNestedRepetition(re *syntax.Regexp, insideRepetition bool) bool {
+	unbounded := isUnboundedRepetition(re)
+	if unbounded && insideRepetition {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasNestedRepetition(sub, insideRepetition || unbounded) {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnboundedRepetition(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1
+	}
+	return false
+}
+
+func trailingBackslashes(s string) int {
+	return len(s) - len(strings.TrimRight(s, `\`))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snippet
+
+import (
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "Plain name", pattern: "GoogleBot"},
+		{name: "Name with slash and dot", pattern: "iaskspider/2.0"},
+		{name: "Inner space", pattern: "Go-http-client 1.1"},
+		{name: "Regex", pattern: `^curl/[0-9.]+$`},
+		{name: "Alternation", pattern: "foo|bar"},
+		{name: "Escaped backslash", pattern: `bot\\`},
+		{name: "Empty", pattern: "", wantErr: true},
+		{name: "Whitespace only", pattern: "   ", wantErr: true},
+		{name: "Leading whitespace", pattern: " GoogleBot", wantErr: true},
+		{name: "Trailing whitespace", pattern: "GoogleBot ", wantErr: true},
+		{name: "Newline", pattern: "Google\nBot", wantErr: true},
+		{name: "Double quote", pattern: `Google"Bot`, wantErr: true},
+		{name: "Curly brace", pattern: "a{2}", wantErr: true},
+		{name: "Trailing backslash", pattern: `bot\`, wantErr: true},
+		{name: "Unbalanced open parenthesis", pattern: "(bot", wantErr: true},
+		{name: "Unbalanced close parenthesis", pattern: "bot)", wantErr: true},
+		{name: "Lookahead", pattern: "bot(?=x)", wantErr: true},
+		{name: "Backreference", pattern: `(a)\1`, wantErr: true},
+		{name: "Possessive quantifier", pattern: "a++", wantErr: true},
+		{name: "Matches everything", pattern: ".*", wantErr: true},
+		{name: "Empty alternative", pattern: "bot|", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePattern(%q) error - got: %v, expected: %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatternWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    int
+	}{
+		{name: "Plain name", pattern: "GoogleBot", want: 0},
+		{name: "Single repetition", pattern: "bot.*crawler", want: 0},
+		{name: "Sibling repetitions", pattern: "a+b+", want: 0},
+		{name: "Nested repetition", pattern: "(a+)+", want: 1},
+		{name: "Nested non capturing repetition", pattern: "(?:a|b*)*x", want: 1},
+		{name: "Deeply nested repetition", pattern: "((ab)*c)+", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PatternWarnings(tt.pattern); len(got) != tt.want {
+				t.Errorf("PatternWarnings(%q) - got: %v, expected %d warnings", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}